@@ -0,0 +1,407 @@
+// Package arrowxlsx writes Apache Arrow record batches to XLSX files through
+// a kolayxlsxstream Writer. It is a separate module, so the core library does
+// not depend on Arrow.
+//
+// Arrow types map to cells as follows:
+//
+//   - Signed integers and uint8 to uint32: numbers
+//   - uint64, float16, float32 and float64: numbers
+//   - Decimals: numbers with a "0.00" style matching the scale
+//   - Booleans: TRUE/FALSE cells
+//   - Strings, large strings and string views: inline strings
+//   - Timestamps: dates in "yyyy-mm-dd hh:mm:ss", using the wall clock time
+//     of the type's time zone (UTC when it has none)
+//   - Date32 and Date64: dates in "yyyy-mm-dd"
+//   - Time32 and Time64: fractions of a day in "hh:mm:ss"
+//   - Dictionaries: the dictionary values, mapped as above
+//   - Nulls: empty cells
+package arrowxlsx
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal"
+	"github.com/turgutahmet/kolayxlsxstream"
+)
+
+// Number formats of the columns that need a style
+const (
+	dateFormat   = "yyyy-mm-dd"
+	timeFormat   = "hh:mm:ss"
+	timeFormatMs = "hh:mm:ss.000"
+)
+
+// batchWriter is where converted batches are written: a kolayxlsxstream
+// Writer or SheetHandle
+type batchWriter interface {
+	WriteBatch(batch *kolayxlsxstream.ColumnBatch) error
+}
+
+// Writer converts record batches of one schema into column batches. Value
+// buffers are reused between records, so a Writer must not be used
+// concurrently.
+type Writer struct {
+	dest    batchWriter
+	schema  *arrow.Schema
+	columns []*column
+	batch   kolayxlsxstream.ColumnBatch
+}
+
+// NewWriter returns a Writer for records with the given schema. Records are
+// written to w's own sheets, or to a named sheet when one is given. Styles
+// for dates, times and decimals are registered on w.
+func NewWriter(w *kolayxlsxstream.Writer, schema *arrow.Schema, sheet ...*kolayxlsxstream.SheetHandle) (*Writer, error) {
+	if len(schema.Fields()) == 0 {
+		return nil, fmt.Errorf("schema has no fields")
+	}
+
+	aw := &Writer{dest: w, schema: schema}
+	if len(sheet) > 0 && sheet[0] != nil {
+		aw.dest = sheet[0]
+	}
+
+	for i, field := range schema.Fields() {
+		c, err := newColumn(w, field.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %d (%s): %w", i, field.Name, err)
+		}
+		aw.columns = append(aw.columns, c)
+	}
+	aw.batch.Columns = make([]kolayxlsxstream.Column, len(aw.columns))
+	return aw, nil
+}
+
+// Headers returns the schema's field names, for use as a header row
+func Headers(schema *arrow.Schema) []interface{} {
+	headers := make([]interface{}, len(schema.Fields()))
+	for i, field := range schema.Fields() {
+		headers[i] = field.Name
+	}
+	return headers
+}
+
+// Write writes the rows of a record. Its columns must have the types of the
+// Writer's schema; field names are not compared.
+func (aw *Writer) Write(rec arrow.RecordBatch) error {
+	if int(rec.NumCols()) != len(aw.columns) {
+		return fmt.Errorf("record has %d columns, expected %d", rec.NumCols(), len(aw.columns))
+	}
+	for i, field := range aw.schema.Fields() {
+		if !arrow.TypeEqual(rec.Column(i).DataType(), field.Type) {
+			return fmt.Errorf("record column %d has type %s, expected %s", i, rec.Column(i).DataType(), field.Type)
+		}
+	}
+
+	for i, c := range aw.columns {
+		aw.batch.Columns[i] = c.convert(rec.Column(i))
+	}
+	return aw.dest.WriteBatch(&aw.batch)
+}
+
+// WriteAll writes every record of a reader
+func (aw *Writer) WriteAll(r array.RecordReader) error {
+	for r.Next() {
+		if err := aw.Write(r.RecordBatch()); err != nil {
+			return err
+		}
+	}
+	return r.Err()
+}
+
+// column converts the arrays of one field into a kolayxlsxstream Column,
+// reusing its value buffers between records
+type column struct {
+	fill  func(c *column, arr arrow.Array) // Sets the value slice of out
+	out   kolayxlsxstream.Column
+	valid []byte
+	dict  *column // Converts the values of a dictionary field
+}
+
+// newColumn returns the converter of a data type, registering its style on w
+func newColumn(w *kolayxlsxstream.Writer, dt arrow.DataType) (*column, error) {
+	c := &column{}
+	numFmt := ""
+
+	switch t := dt.(type) {
+	case *arrow.Int8Type:
+		c.fill = fillInts[int8]
+	case *arrow.Int16Type:
+		c.fill = fillInts[int16]
+	case *arrow.Int32Type:
+		c.fill = fillInts[int32]
+	case *arrow.Int64Type:
+		// Used as they are, without copying
+		c.fill = func(c *column, arr arrow.Array) { c.out.Ints = nonNil(arr.(*array.Int64).Int64Values()) }
+	case *arrow.Uint8Type:
+		c.fill = fillInts[uint8]
+	case *arrow.Uint16Type:
+		c.fill = fillInts[uint16]
+	case *arrow.Uint32Type:
+		c.fill = fillInts[uint32]
+	case *arrow.Uint64Type:
+		// Excel numbers are doubles, so values above 2^53 lose precision
+		c.fill = fillFloats[uint64]
+	case *arrow.Float16Type:
+		c.fill = func(c *column, arr arrow.Array) {
+			a := arr.(*array.Float16)
+			c.out.Floats = grow(c.out.Floats, a.Len())
+			for i := range c.out.Floats {
+				c.out.Floats[i] = float64(a.Value(i).Float32())
+			}
+		}
+	case *arrow.Float32Type:
+		c.fill = fillFloats[float32]
+	case *arrow.Float64Type:
+		c.fill = func(c *column, arr arrow.Array) { c.out.Floats = nonNil(arr.(*array.Float64).Float64Values()) }
+	case *arrow.Decimal32Type:
+		c.fill = fillDecimals[decimal.Decimal32](t.Scale)
+		numFmt = decimalFormat(t.Scale)
+	case *arrow.Decimal64Type:
+		c.fill = fillDecimals[decimal.Decimal64](t.Scale)
+		numFmt = decimalFormat(t.Scale)
+	case *arrow.Decimal128Type:
+		c.fill = fillDecimals[decimal.Decimal128](t.Scale)
+		numFmt = decimalFormat(t.Scale)
+	case *arrow.Decimal256Type:
+		c.fill = fillDecimals[decimal.Decimal256](t.Scale)
+		numFmt = decimalFormat(t.Scale)
+	case *arrow.BooleanType:
+		c.fill = func(c *column, arr arrow.Array) {
+			a := arr.(*array.Boolean)
+			c.out.Bools = grow(c.out.Bools, a.Len())
+			for i := range c.out.Bools {
+				c.out.Bools[i] = a.Value(i)
+			}
+		}
+	case *arrow.StringType, *arrow.LargeStringType, *arrow.StringViewType:
+		c.fill = func(c *column, arr arrow.Array) {
+			a := arr.(array.StringLike)
+			c.out.Strings = grow(c.out.Strings, a.Len())
+			for i := range c.out.Strings {
+				c.out.Strings[i] = a.Value(i)
+			}
+		}
+	case *arrow.TimestampType:
+		toTime, err := t.GetToTimeFunc()
+		if err != nil {
+			return nil, err
+		}
+		c.fill = func(c *column, arr arrow.Array) {
+			a := arr.(*array.Timestamp)
+			c.out.Times = grow(c.out.Times, a.Len())
+			for i := range c.out.Times {
+				c.out.Times[i] = toTime(a.Value(i))
+			}
+		}
+	case *arrow.Date32Type:
+		c.fill = fillDates[arrow.Date32]
+		numFmt = dateFormat
+	case *arrow.Date64Type:
+		c.fill = fillDates[arrow.Date64]
+		numFmt = dateFormat
+	case *arrow.Time32Type:
+		c.fill = fillTimesOfDay[arrow.Time32](t.Unit)
+		numFmt = timeOfDayFormat(t.Unit)
+	case *arrow.Time64Type:
+		c.fill = fillTimesOfDay[arrow.Time64](t.Unit)
+		numFmt = timeOfDayFormat(t.Unit)
+	case *arrow.DictionaryType:
+		dict, err := newColumn(w, t.ValueType)
+		if err != nil {
+			return nil, fmt.Errorf("dictionary values: %w", err)
+		}
+		c.dict = dict
+		c.out.StyleID = dict.out.StyleID
+		return c, nil
+	case *arrow.NullType:
+		c.fill = func(c *column, arr arrow.Array) {
+			c.out.Strings = grow(c.out.Strings, arr.Len())
+		}
+	default:
+		return nil, fmt.Errorf("unsupported type %s", dt)
+	}
+
+	if numFmt != "" {
+		id, err := w.AddStyle(kolayxlsxstream.Style{NumFmt: numFmt})
+		if err != nil {
+			return nil, err
+		}
+		c.out.StyleID = id
+	}
+	return c, nil
+}
+
+// convert returns the column's values for an array. The slices are only
+// valid until the next call.
+func (c *column) convert(arr arrow.Array) kolayxlsxstream.Column {
+	if c.dict != nil {
+		return c.gather(arr.(*array.Dictionary))
+	}
+
+	c.fill(c, arr)
+	c.out.Valid = nil
+	// Null arrays have no validity bitmap, so IsValid reports every row as valid
+	if isNull := arr.DataType().ID() == arrow.NULL; isNull || arr.NullN() > 0 {
+		c.valid = grow(c.valid, (arr.Len()+7)/8)
+		clear(c.valid)
+		for i := range arr.Len() {
+			if !isNull && arr.IsValid(i) {
+				c.valid[i/8] |= 1 << (i % 8)
+			}
+		}
+		c.out.Valid = c.valid
+	}
+	return c.out
+}
+
+// gather returns the dictionary value of every row of a dictionary array.
+// A row is null when its index or its dictionary value is null.
+func (c *column) gather(arr *array.Dictionary) kolayxlsxstream.Column {
+	values := c.dict.convert(arr.Dictionary())
+	n := arr.Len()
+
+	c.valid = grow(c.valid, (n+7)/8)
+	clear(c.valid)
+	index := func(i int) (int, bool) {
+		if arr.IsNull(i) {
+			return 0, false
+		}
+		j := arr.GetValueIndex(i)
+		if values.Valid != nil && values.Valid[j/8]&(1<<(j%8)) == 0 {
+			return 0, false
+		}
+		c.valid[i/8] |= 1 << (i % 8)
+		return j, true
+	}
+
+	switch {
+	case values.Ints != nil:
+		c.out.Ints = gatherValues(c.out.Ints, values.Ints, n, index)
+	case values.Floats != nil:
+		c.out.Floats = gatherValues(c.out.Floats, values.Floats, n, index)
+	case values.Strings != nil:
+		c.out.Strings = gatherValues(c.out.Strings, values.Strings, n, index)
+	case values.Bools != nil:
+		c.out.Bools = gatherValues(c.out.Bools, values.Bools, n, index)
+	default:
+		c.out.Times = gatherValues(c.out.Times, values.Times, n, index)
+	}
+	c.out.Valid = c.valid
+	return c.out
+}
+
+// gatherValues sets dst[i] to the dictionary value of row i, leaving null
+// rows at the zero value
+func gatherValues[T any](dst, values []T, n int, index func(int) (int, bool)) []T {
+	dst = grow(dst, n)
+	var zero T
+	for i := range dst {
+		if j, ok := index(i); ok {
+			dst[i] = values[j]
+		} else {
+			dst[i] = zero
+		}
+	}
+	return dst
+}
+
+// grow returns a non-nil slice of length n, reusing s when it is large enough
+func grow[T any](s []T, n int) []T {
+	if s == nil || cap(s) < n {
+		return make([]T, n)
+	}
+	return s[:n]
+}
+
+// nonNil returns s, or an empty slice when s is nil, since a column's value
+// slice must be set even when it has no rows
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
+
+// valueArray is an Arrow array of fixed-width values
+type valueArray[T any] interface {
+	arrow.Array
+	Value(i int) T
+}
+
+// integer is an Arrow integer type that fits in an int64
+type integer interface {
+	~int8 | ~int16 | ~int32 | ~int64 | ~uint8 | ~uint16 | ~uint32
+}
+
+// fillInts converts an integer array to Ints
+func fillInts[T integer](c *column, arr arrow.Array) {
+	a := arr.(valueArray[T])
+	c.out.Ints = grow(c.out.Ints, a.Len())
+	for i := range c.out.Ints {
+		c.out.Ints[i] = int64(a.Value(i))
+	}
+}
+
+// fillFloats converts a numeric array to Floats
+func fillFloats[T ~float32 | ~uint64](c *column, arr arrow.Array) {
+	a := arr.(valueArray[T])
+	c.out.Floats = grow(c.out.Floats, a.Len())
+	for i := range c.out.Floats {
+		c.out.Floats[i] = float64(a.Value(i))
+	}
+}
+
+// fillDecimals returns a converter of decimal arrays with a scale to Floats
+func fillDecimals[T interface{ ToFloat64(int32) float64 }](scale int32) func(*column, arrow.Array) {
+	return func(c *column, arr arrow.Array) {
+		a := arr.(valueArray[T])
+		c.out.Floats = grow(c.out.Floats, a.Len())
+		for i := range c.out.Floats {
+			c.out.Floats[i] = a.Value(i).ToFloat64(scale)
+		}
+	}
+}
+
+// fillDates converts a date array to Times at midnight UTC
+func fillDates[T interface{ ToTime() time.Time }](c *column, arr arrow.Array) {
+	a := arr.(valueArray[T])
+	c.out.Times = grow(c.out.Times, a.Len())
+	for i := range c.out.Times {
+		c.out.Times[i] = a.Value(i).ToTime()
+	}
+}
+
+// fillTimesOfDay returns a converter of time arrays in a unit to Floats
+// holding fractions of a day, as Excel stores times
+func fillTimesOfDay[T ~int32 | ~int64](unit arrow.TimeUnit) func(*column, arrow.Array) {
+	perDay := float64(24*time.Hour) / float64(unit.Multiplier())
+	return func(c *column, arr arrow.Array) {
+		a := arr.(valueArray[T])
+		c.out.Floats = grow(c.out.Floats, a.Len())
+		for i := range c.out.Floats {
+			c.out.Floats[i] = float64(a.Value(i)) / perDay
+		}
+	}
+}
+
+// decimalFormat returns the number format showing a decimal's scale
+func decimalFormat(scale int32) string {
+	if scale <= 0 {
+		return "0"
+	}
+	// Excel keeps 15 significant digits
+	return "0." + strings.Repeat("0", int(min(scale, 15)))
+}
+
+// timeOfDayFormat returns the number format of times in a unit, showing
+// milliseconds when the unit is finer than seconds
+func timeOfDayFormat(unit arrow.TimeUnit) string {
+	if unit == arrow.Second {
+		return timeFormat
+	}
+	return timeFormatMs
+}
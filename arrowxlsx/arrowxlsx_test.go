@@ -0,0 +1,289 @@
+package arrowxlsx
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow-go/v18/arrow"
+	"github.com/apache/arrow-go/v18/arrow/array"
+	"github.com/apache/arrow-go/v18/arrow/decimal128"
+	"github.com/apache/arrow-go/v18/arrow/memory"
+	"github.com/turgutahmet/kolayxlsxstream"
+)
+
+// readZipEntry returns the contents of a file inside an XLSX archive
+func readZipEntry(t *testing.T, path, name string) string {
+	t.Helper()
+
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open output as ZIP: %v", err)
+	}
+	defer zipReader.Close()
+
+	for _, f := range zipReader.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(data)
+	}
+
+	t.Fatalf("Entry %s not found in ZIP", name)
+	return ""
+}
+
+// testSchema has one field of every mapped type
+var testSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "id", Type: arrow.PrimitiveTypes.Int32},
+	{Name: "count", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+	{Name: "big", Type: arrow.PrimitiveTypes.Uint64},
+	{Name: "ratio", Type: arrow.PrimitiveTypes.Float64},
+	{Name: "price", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}},
+	{Name: "active", Type: arrow.FixedWidthTypes.Boolean},
+	{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	{Name: "created", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "Europe/Istanbul"}},
+	{Name: "day", Type: arrow.FixedWidthTypes.Date32},
+	{Name: "at", Type: arrow.FixedWidthTypes.Time32ms},
+	{Name: "city", Type: &arrow.DictionaryType{IndexType: arrow.PrimitiveTypes.Int8, ValueType: arrow.BinaryTypes.String}, Nullable: true},
+	{Name: "nothing", Type: arrow.Null, Nullable: true},
+}, nil)
+
+// buildRecord returns a record of testSchema with two rows, the second
+// mostly null
+func buildRecord(t *testing.T) arrow.RecordBatch {
+	t.Helper()
+
+	b := array.NewRecordBuilder(memory.DefaultAllocator, testSchema)
+	defer b.Release()
+
+	b.Field(0).(*array.Int32Builder).AppendValues([]int32{1, 2}, nil)
+	b.Field(1).(*array.Int64Builder).AppendValues([]int64{42, 0}, []bool{true, false})
+	b.Field(2).(*array.Uint64Builder).AppendValues([]uint64{1 << 60, 7}, nil)
+	b.Field(3).(*array.Float64Builder).AppendValues([]float64{0.25, -1.5}, nil)
+	b.Field(4).(*array.Decimal128Builder).AppendValues([]decimal128.Num{decimal128.FromI64(123456), decimal128.FromI64(-5)}, nil)
+	b.Field(5).(*array.BooleanBuilder).AppendValues([]bool{true, false}, nil)
+	b.Field(6).(*array.StringBuilder).AppendValues([]string{"Tom & Jerry", ""}, []bool{true, false})
+
+	// 2024-01-15 09:00 UTC is 12:00 in Istanbul
+	created := time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC).UnixMilli()
+	b.Field(7).(*array.TimestampBuilder).AppendValues([]arrow.Timestamp{arrow.Timestamp(created), arrow.Timestamp(created)}, nil)
+	b.Field(8).(*array.Date32Builder).AppendValues([]arrow.Date32{arrow.Date32FromTime(time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC)), 0}, nil)
+	b.Field(9).(*array.Time32Builder).AppendValues([]arrow.Time32{6 * 60 * 60 * 1000, 0}, nil)
+
+	city := b.Field(10).(*array.BinaryDictionaryBuilder)
+	if err := city.AppendString("Ankara"); err != nil {
+		t.Fatalf("Failed to append dictionary value: %v", err)
+	}
+	city.AppendNull()
+	b.Field(11).(*array.NullBuilder).AppendNulls(2)
+
+	return b.NewRecordBatch()
+}
+
+func TestWriteRecords(t *testing.T) {
+	tmpFile := "test_arrow.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := kolayxlsxstream.NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := kolayxlsxstream.NewWriter(sink)
+	if err := writer.StartFile(Headers(testSchema)); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	aw, err := NewWriter(writer, testSchema)
+	if err != nil {
+		t.Fatalf("Failed to create Arrow writer: %v", err)
+	}
+	rec := buildRecord(t)
+	defer rec.Release()
+	for i := 0; i < 2; i++ {
+		if err := aw.Write(rec); err != nil {
+			t.Fatalf("Failed to write record: %v", err)
+		}
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 4 {
+		t.Errorf("Expected 4 rows, got %d", stats.TotalRows)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>id</t></is></c>`,
+		`<row r="2"><c r="A2"><v>1</v></c><c r="B2"><v>42</v></c><c r="C2"><v>1152921504606847000</v></c><c r="D2"><v>0.25</v></c>` +
+			`<c r="E2" s="2"><v>1234.56</v></c><c r="F2" t="b"><v>1</v></c><c r="G2" t="inlineStr"><is><t>Tom &amp; Jerry</t></is></c>` +
+			`<c r="H2" s="5"><v>45306.5</v></c><c r="I2" s="3"><v>45306</v></c><c r="J2" s="4"><v>0.25</v></c>` +
+			`<c r="K2" t="inlineStr"><is><t>Ankara</t></is></c><c r="L2"/></row>`,
+		`<row r="3"><c r="A3"><v>2</v></c><c r="B3"/><c r="C3"><v>7</v></c><c r="D3"><v>-1.5</v></c>` +
+			`<c r="E3" s="2"><v>-0.05</v></c><c r="F3" t="b"><v>0</v></c><c r="G3"/>` +
+			`<c r="H3" s="5"><v>45306.5</v></c><c r="I3" s="3"><v>25569</v></c><c r="J3" s="4"><v>0</v></c>` +
+			`<c r="K3"/><c r="L3"/></row>`,
+		`<row r="5"><c r="A5"><v>2</v></c>`,
+	} {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+
+	styles := readZipEntry(t, tmpFile, "xl/styles.xml")
+	for _, e := range []string{
+		`<xf numFmtId="2" `,
+		`formatCode="yyyy-mm-dd"`,
+		`formatCode="hh:mm:ss.000"`,
+		`formatCode="yyyy-mm-dd hh:mm:ss"`,
+	} {
+		if !strings.Contains(styles, e) {
+			t.Errorf("Expected %s in styles, got %s", e, styles)
+		}
+	}
+}
+
+func TestWriteRecordsToSheet(t *testing.T) {
+	tmpFile := "test_arrow_sheet.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := kolayxlsxstream.NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := kolayxlsxstream.NewWriter(sink)
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	sheet, err := writer.Sheet("Arrow", Headers(testSchema))
+	if err != nil {
+		t.Fatalf("Failed to add sheet: %v", err)
+	}
+
+	aw, err := NewWriter(writer, testSchema, sheet)
+	if err != nil {
+		t.Fatalf("Failed to create Arrow writer: %v", err)
+	}
+	rec := buildRecord(t)
+	defer rec.Release()
+	reader, err := array.NewRecordReader(testSchema, []arrow.RecordBatch{rec, rec.NewSlice(1, 2)})
+	if err != nil {
+		t.Fatalf("Failed to create record reader: %v", err)
+	}
+	defer reader.Release()
+	if err := aw.WriteAll(reader); err != nil {
+		t.Fatalf("Failed to write records: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	content := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	// The slice starts at the second row, with its nulls at an offset
+	for _, e := range []string{
+		`<row r="3"><c r="A3"><v>2</v></c><c r="B3"/>`,
+		`<row r="4"><c r="A4"><v>2</v></c><c r="B4"/><c r="C4"><v>7</v></c>`,
+		`<c r="G4"/>`,
+		`<c r="K4"/><c r="L4"/></row>`,
+	} {
+		if !strings.Contains(content, e) {
+			t.Errorf("Expected %s in named sheet, got %s", e, content)
+		}
+	}
+}
+
+func TestWriteFarDates(t *testing.T) {
+	tmpFile := "test_arrow_dates.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := kolayxlsxstream.NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := kolayxlsxstream.NewWriter(sink)
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	schema := arrow.NewSchema([]arrow.Field{
+		{Name: "at", Type: &arrow.TimestampType{Unit: arrow.Second}},
+		{Name: "day", Type: arrow.FixedWidthTypes.Date32},
+		{Name: "day64", Type: arrow.FixedWidthTypes.Date64},
+	}, nil)
+	b := array.NewRecordBuilder(memory.DefaultAllocator, schema)
+	defer b.Release()
+
+	// The last day Excel supports, beyond the range of a time.Duration
+	last := time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC)
+	b.Field(0).(*array.TimestampBuilder).Append(arrow.Timestamp(last.Add(12 * time.Hour).Unix()))
+	b.Field(1).(*array.Date32Builder).Append(arrow.Date32FromTime(last))
+	b.Field(2).(*array.Date64Builder).Append(arrow.Date64FromTime(last))
+	rec := b.NewRecordBatch()
+	defer rec.Release()
+
+	aw, err := NewWriter(writer, schema)
+	if err != nil {
+		t.Fatalf("Failed to create Arrow writer: %v", err)
+	}
+	if err := aw.Write(rec); err != nil {
+		t.Fatalf("Failed to write record: %v", err)
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	e := `<c r="A1" s="3"><v>2958465.5</v></c><c r="B1" s="2"><v>2958465</v></c><c r="C1" s="2"><v>2958465</v></c>`
+	if !strings.Contains(sheet, e) {
+		t.Errorf("Expected %s in sheet, got %s", e, sheet)
+	}
+}
+
+func TestWriterValidation(t *testing.T) {
+	writer := kolayxlsxstream.NewWriter(nil)
+
+	unsupported := arrow.NewSchema([]arrow.Field{{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String)}}, nil)
+	if _, err := NewWriter(writer, unsupported); err == nil || !strings.Contains(err.Error(), "unsupported type") {
+		t.Errorf("Expected unsupported type error, got %v", err)
+	}
+	if _, err := NewWriter(writer, arrow.NewSchema(nil, nil)); err == nil {
+		t.Error("Expected error for a schema without fields")
+	}
+
+	other := arrow.NewSchema([]arrow.Field{{Name: "id", Type: arrow.PrimitiveTypes.Int64}}, nil)
+	aw, err := NewWriter(writer, other)
+	if err != nil {
+		t.Fatalf("Failed to create Arrow writer: %v", err)
+	}
+	rec := buildRecord(t)
+	defer rec.Release()
+	if err := aw.Write(rec); err == nil || !strings.Contains(err.Error(), "expected 1") {
+		t.Errorf("Expected column count error, got %v", err)
+	}
+
+	id := arrow.NewSchema([]arrow.Field{{Name: "id", Type: arrow.PrimitiveTypes.Int32}}, nil)
+	aw, err = NewWriter(writer, id)
+	if err != nil {
+		t.Fatalf("Failed to create Arrow writer: %v", err)
+	}
+	single := array.NewRecordBatch(arrow.NewSchema([]arrow.Field{{Name: "other", Type: arrow.PrimitiveTypes.Int64}}, nil), []arrow.Array{rec.Column(1)}, rec.NumRows())
+	defer single.Release()
+	if err := aw.Write(single); err == nil || !strings.Contains(err.Error(), "has type int64, expected int32") {
+		t.Errorf("Expected column type error, got %v", err)
+	}
+}
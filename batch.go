@@ -0,0 +1,197 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// defaultDateTimeFormat is the number format of time columns without a style
+const defaultDateTimeFormat = "yyyy-mm-dd hh:mm:ss"
+
+// ColumnBatch is a set of typed columns of equal length, written as rows by
+// WriteBatch without boxing every value in an interface{}. It maps directly
+// onto columnar sources such as Arrow record batches or database cursors.
+type ColumnBatch struct {
+	Columns []Column
+}
+
+// Column is one column of a ColumnBatch. Exactly one of the value slices must
+// be set; its length is the number of rows.
+type Column struct {
+	Ints    []int64
+	Floats  []float64
+	Strings []string
+	Bools   []bool
+
+	// Times are written as Excel date serial numbers using the wall clock
+	// time in each value's location. Zero times are written as empty cells.
+	Times []time.Time
+
+	// Valid is an optional validity bitmap, least significant bit first as
+	// in Apache Arrow: row i has a value when bit i is set. Rows without a
+	// value are written as empty cells. Nil means every row has a value.
+	Valid []byte
+
+	// StyleID is a style registered with Writer.AddStyle. Time columns
+	// without a style use a built-in "yyyy-mm-dd hh:mm:ss" style.
+	StyleID int
+}
+
+// Len returns the number of rows in the batch
+func (b *ColumnBatch) Len() int {
+	if len(b.Columns) == 0 {
+		return 0
+	}
+	return b.Columns[0].len()
+}
+
+// len returns the number of values in the column
+func (c *Column) len() int {
+	switch {
+	case c.Ints != nil:
+		return len(c.Ints)
+	case c.Floats != nil:
+		return len(c.Floats)
+	case c.Strings != nil:
+		return len(c.Strings)
+	case c.Bools != nil:
+		return len(c.Bools)
+	default:
+		return len(c.Times)
+	}
+}
+
+// valid reports whether row i of the column has a value
+func (c *Column) valid(i int) bool {
+	return c.Valid == nil || c.Valid[i/8]&(1<<(i%8)) != 0
+}
+
+// validate checks that every column has exactly one value slice of the
+// batch's length and a large enough validity bitmap
+func (b *ColumnBatch) validate(styleCount int) error {
+	rows := b.Len()
+	for i := range b.Columns {
+		c := &b.Columns[i]
+
+		set := 0
+		for _, isSet := range []bool{c.Ints != nil, c.Floats != nil, c.Strings != nil, c.Bools != nil, c.Times != nil} {
+			if isSet {
+				set++
+			}
+		}
+		if set != 1 {
+			return fmt.Errorf("batch column %d must have exactly one value slice, has %d", i, set)
+		}
+		if c.len() != rows {
+			return fmt.Errorf("batch column %d has %d rows, expected %d", i, c.len(), rows)
+		}
+		if c.Valid != nil && len(c.Valid) < (rows+7)/8 {
+			return fmt.Errorf("batch column %d validity bitmap is too short for %d rows", i, rows)
+		}
+		if c.StyleID < 0 || c.StyleID >= styleCount {
+			return fmt.Errorf("batch column %d: unknown style ID %d", i, c.StyleID)
+		}
+	}
+	if len(b.Columns) > maxColumns {
+		return fmt.Errorf("batch has %d columns, more than the maximum of %d", len(b.Columns), maxColumns)
+	}
+	return nil
+}
+
+// WriteBatch writes the rows of a column batch to the current sheet, rolling
+// over to new sheets as they fill up. As with WriteRows, the rows before a
+// failing row are written.
+func (w *Writer) WriteBatch(batch *ColumnBatch) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+	if err := batch.validate(w.styles.cellStyleCount()); err != nil {
+		return err
+	}
+
+	styles, err := w.batchStyles(batch)
+	if err != nil {
+		return err
+	}
+
+	for i := range batch.Len() {
+		sw, err := w.rowSheet()
+		if err != nil {
+			return err
+		}
+		if err := sw.writeBatchRow(batch, i, styles); err != nil {
+			return err
+		}
+		w.totalRows++
+	}
+	return nil
+}
+
+// batchStyles returns the style of each column, registering the built-in
+// date-time style for time columns without one
+func (w *Writer) batchStyles(batch *ColumnBatch) ([]int, error) {
+	styles := make([]int, len(batch.Columns))
+	for i, c := range batch.Columns {
+		styles[i] = c.StyleID
+		if c.Times != nil && c.StyleID == 0 {
+			id, err := w.styles.addCellStyle(Style{NumFmt: defaultDateTimeFormat})
+			if err != nil {
+				return nil, err
+			}
+			styles[i] = id
+		}
+	}
+	return styles, nil
+}
+
+// writeBatchRow generates and writes the XML for the sheet's next row from
+// row i of a batch
+func (sw *sheetWriter) writeBatchRow(batch *ColumnBatch, i int, styles []int) error {
+	sw.growColumns(len(batch.Columns))
+	buf, err := sw.appendBatchRow(sw.buf[:0], sw.rowCount, batch, i, styles)
+	sw.buf = buf
+	if err != nil {
+		return fmt.Errorf("failed to generate row: %w", err)
+	}
+	return sw.writeRowXML(buf)
+}
+
+// appendBatchRow appends the XML of row i of a batch, followed by a newline
+func (sw *sheetWriter) appendBatchRow(dst []byte, rowIndex int, batch *ColumnBatch, i int, styles []int) ([]byte, error) {
+	dst = append(dst, `<row r="`...)
+	dst = strconv.AppendInt(dst, int64(rowIndex+1), 10)
+	dst = append(dst, `">`...)
+
+	for col := range batch.Columns {
+		c := &batch.Columns[col]
+		ref := sw.cellRef(rowIndex, col)
+		style := styles[col]
+
+		if !c.valid(i) || (c.Times != nil && c.Times[i].IsZero()) {
+			if style > 0 || !sw.config.OmitEmptyCells {
+				dst = appendEmptyCell(dst, ref, style)
+			}
+			continue
+		}
+
+		switch {
+		case c.Ints != nil:
+			dst = appendIntCell(dst, ref, style, c.Ints[i])
+		case c.Floats != nil:
+			var err error
+			if dst, err = appendFloatCell(dst, ref, style, c.Floats[i], 64, sw.config.NonFiniteFloatPolicy); err != nil {
+				return dst, err
+			}
+		case c.Strings != nil:
+			dst = appendStringCell(dst, ref, style, c.Strings[i])
+		case c.Bools != nil:
+			dst = appendBoolCell(dst, ref, style, c.Bools[i])
+		default:
+			// Date serials are always finite, so this cannot fail
+			dst, _ = appendFloatCell(dst, ref, style, timeToSerial(c.Times[i]), 64, NonFiniteEmpty)
+		}
+	}
+
+	return append(dst, "</row>\n"...), nil
+}
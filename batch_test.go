@@ -0,0 +1,206 @@
+package kolayxlsxstream
+
+import (
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteBatch(t *testing.T) {
+	tmpFile := "test_batch.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID", "Amount", "Name", "Active", "Created"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	money, err := writer.AddStyle(Style{NumFmt: "#,##0.00"})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+
+	istanbul := time.FixedZone("TRT", 3*60*60)
+	batch := &ColumnBatch{Columns: []Column{
+		{Ints: []int64{1, 2, 3, 4, 5}, Valid: []byte{0b11011}},
+		{Floats: []float64{10.5, math.NaN(), 0, -1e21, 1}, StyleID: money},
+		{Strings: []string{"a & b", "", " padded", "d", "e"}},
+		{Bools: []bool{true, false, true, false, true}},
+		{Times: []time.Time{
+			time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC),
+			{},
+			time.Date(1900, 3, 1, 6, 0, 0, 0, istanbul),
+			time.Date(2024, 1, 15, 0, 0, 0, 0, istanbul),
+			time.Date(9999, 12, 31, 12, 0, 0, 0, time.UTC),
+		}},
+	}}
+	if batch.Len() != 5 {
+		t.Errorf("Expected 5 rows, got %d", batch.Len())
+	}
+
+	if err := writer.WriteBatch(batch); err != nil {
+		t.Fatalf("Failed to write batch: %v", err)
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 5 || stats.TotalSheets != 2 {
+		t.Errorf("Expected 5 rows on 2 sheets, got %d rows on %d sheets", stats.TotalRows, stats.TotalSheets)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<row r="2"><c r="A2"><v>1</v></c><c r="B2" s="2"><v>10.5</v></c><c r="C2" t="inlineStr"><is><t>a &amp; b</t></is></c><c r="D2" t="b"><v>1</v></c><c r="E2" s="3"><v>45306.5</v></c></row>`,
+		`<row r="3"><c r="A3"><v>2</v></c><c r="B3" s="2"/><c r="C3" t="inlineStr"><is><t></t></is></c><c r="D3" t="b"><v>0</v></c><c r="E3" s="3"/></row>`,
+	} {
+		if !strings.Contains(sheet1, e) {
+			t.Errorf("Expected %s in sheet 1, got %s", e, sheet1)
+		}
+	}
+
+	sheet2 := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	for _, e := range []string{
+		`<row r="1"><c r="A1"/><c r="B1" s="2"><v>0</v></c><c r="C1" t="inlineStr"><is><t xml:space="preserve"> padded</t></is></c>`,
+		`<c r="E1" s="3"><v>61.25</v></c>`,
+		`<c r="B2" s="2"><v>-1E+21</v></c>`,
+		`<c r="E2" s="3"><v>45306</v></c>`,
+		// The last day Excel supports
+		`<c r="E3" s="3"><v>2958465.5</v></c>`,
+	} {
+		if !strings.Contains(sheet2, e) {
+			t.Errorf("Expected %s in sheet 2, got %s", e, sheet2)
+		}
+	}
+
+	styles := readZipEntry(t, tmpFile, "xl/styles.xml")
+	if !strings.Contains(styles, `formatCode="yyyy-mm-dd hh:mm:ss"`) {
+		t.Errorf("Expected built-in date-time format, got %s", styles)
+	}
+}
+
+func TestWriteBatchValidation(t *testing.T) {
+	writer := NewWriter(discardSink{})
+	if err := writer.WriteBatch(&ColumnBatch{}); err == nil {
+		t.Error("Expected error before StartFile")
+	}
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	invalid := []*ColumnBatch{
+		{Columns: []Column{{}}},
+		{Columns: []Column{{Ints: []int64{1}, Strings: []string{"a"}}}},
+		{Columns: []Column{{Ints: []int64{1, 2}}, {Floats: []float64{1}}}},
+		{Columns: []Column{{Ints: make([]int64, 9), Valid: []byte{0xff}}}},
+		{Columns: []Column{{Bools: []bool{true}, StyleID: 7}}},
+	}
+	for i, batch := range invalid {
+		if err := writer.WriteBatch(batch); err == nil {
+			t.Errorf("Expected error for batch %d", i)
+		}
+	}
+
+	config := DefaultConfig()
+	config.NonFiniteFloatPolicy = NonFiniteError
+	writer = NewWriter(discardSink{}, config)
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	if err := writer.WriteBatch(&ColumnBatch{Columns: []Column{{Floats: []float64{1, math.Inf(1), 3}}}}); err == nil {
+		t.Error("Expected error for non-finite float")
+	}
+	if writer.totalRows != 1 {
+		t.Errorf("Expected the row before the failing one to be written, got %d rows", writer.totalRows)
+	}
+}
+
+func TestWriteBatchAllocations(t *testing.T) {
+	sw := newTestSheetWriter(nil)
+	sw.writer = discardSink{}
+	batch := &ColumnBatch{Columns: []Column{
+		{Ints: []int64{42}},
+		{Floats: []float64{1.5}},
+		{Strings: []string{"text"}},
+		{Times: []time.Time{time.Now()}},
+	}}
+	styles := []int{0, 0, 0, 0}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := sw.writeBatchRow(batch, 0, styles); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	})
+	if allocs > 0 {
+		t.Errorf("Expected no allocations per row, got %v", allocs)
+	}
+}
+
+// benchmarkColumns returns a batch of typed columns
+func benchmarkColumns(n int) *ColumnBatch {
+	ids := make([]int64, n)
+	amounts := make([]float64, n)
+	names := make([]string, n)
+	created := make([]time.Time, n)
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		ids[i] = int64(i)
+		amounts[i] = float64(i) * 1.25
+		names[i] = "Customer"
+		created[i] = start.Add(time.Duration(i) * time.Minute)
+	}
+
+	return &ColumnBatch{Columns: []Column{{Ints: ids}, {Floats: amounts}, {Strings: names}, {Times: created}}}
+}
+
+// benchmarkWriteColumns writes 100k rows of typed columns to a discarding
+// sink, either as a batch or boxed into one []interface{} per row
+func benchmarkWriteColumns(b *testing.B, boxed bool) {
+	batch := benchmarkColumns(100000)
+	c := batch.Columns
+	b.ReportAllocs()
+
+	for b.Loop() {
+		config := DefaultConfig()
+		config.CompressionLevel = 1
+		writer := NewWriter(discardSink{}, config)
+		if err := writer.StartFile(); err != nil {
+			b.Fatalf("Failed to start file: %v", err)
+		}
+
+		if boxed {
+			row := make([]interface{}, 4)
+			for i := range batch.Len() {
+				row[0], row[1], row[2], row[3] = c[0].Ints[i], c[1].Floats[i], c[2].Strings[i], timeToSerial(c[3].Times[i])
+				if err := writer.WriteRow(row); err != nil {
+					b.Fatalf("Failed to write row: %v", err)
+				}
+			}
+		} else if err := writer.WriteBatch(batch); err != nil {
+			b.Fatalf("Failed to write batch: %v", err)
+		}
+
+		if _, err := writer.FinishFile(); err != nil {
+			b.Fatalf("Failed to finish file: %v", err)
+		}
+	}
+}
+
+func BenchmarkWriteBatch(b *testing.B) {
+	benchmarkWriteColumns(b, false)
+}
+
+func BenchmarkWriteBatchBoxedRows(b *testing.B) {
+	benchmarkWriteColumns(b, true)
+}
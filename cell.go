@@ -0,0 +1,227 @@
+package kolayxlsxstream
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrorValue is an Excel error value written as an error cell (t="e"),
+// so formulas referencing it behave as they would in Excel
+type ErrorValue string
+
+// Excel error values
+const (
+	ErrorNull ErrorValue = "#NULL!"
+	ErrorDiv0 ErrorValue = "#DIV/0!"
+	ErrorVal  ErrorValue = "#VALUE!"
+	ErrorRef  ErrorValue = "#REF!"
+	ErrorName ErrorValue = "#NAME?"
+	ErrorNum  ErrorValue = "#NUM!"
+	ErrorNA   ErrorValue = "#N/A"
+)
+
+// valid reports whether e is one of the error values Excel understands
+func (e ErrorValue) valid() bool {
+	switch e {
+	case ErrorNull, ErrorDiv0, ErrorVal, ErrorRef, ErrorName, ErrorNum, ErrorNA:
+		return true
+	}
+	return false
+}
+
+// CellType forces the type a Cell value is stored as
+type CellType int
+
+const (
+	// CellTypeAuto stores the value according to its Go type
+	CellTypeAuto CellType = iota
+	// CellTypeString stores the value as text, e.g. ZIP codes or account IDs
+	// that would otherwise lose leading zeros or precision as numbers
+	CellTypeString
+	// CellTypeNumber stores the value as a number; strings must parse as one
+	// and integers beyond 2^53, which a number cannot hold exactly, are
+	// rejected
+	CellTypeNumber
+	// CellTypeBool stores the value as a boolean; strings must parse as one
+	// and numbers are true when non-zero
+	CellTypeBool
+)
+
+// Cell wraps a value written by WriteRow with explicit rendering options
+type Cell struct {
+	Value interface{}
+	Type  CellType
+
+	// StyleID is a style registered with Writer.AddStyle (default: 0, no style)
+	StyleID int
+}
+
+// appendTypedCell appends the XML for a Cell, converting its value to the
+// requested type
+func (sw *sheetWriter) appendTypedCell(dst []byte, ref cellRef, c Cell) ([]byte, error) {
+	if c.StyleID < 0 || c.StyleID >= sw.styles.cellStyleCount() {
+		return dst, fmt.Errorf("cell %s: unknown style ID %d", ref, c.StyleID)
+	}
+	if c.Value == nil {
+		return appendEmptyCell(dst, ref, c.StyleID), nil
+	}
+
+	switch c.Type {
+	case CellTypeAuto:
+		if _, nested := c.Value.(Cell); nested {
+			return dst, fmt.Errorf("cell %s: nested Cell values are not supported", ref)
+		}
+		return sw.appendCell(dst, ref, c.StyleID, c.Value)
+	case CellTypeString:
+		return appendStringCell(dst, ref, c.StyleID, formatText(c.Value)), nil
+	case CellTypeNumber:
+		f, bitSize, err := toNumber(c.Value)
+		if err != nil {
+			return dst, fmt.Errorf("cell %s: %w", ref, err)
+		}
+		return appendFloatCell(dst, ref, c.StyleID, f, bitSize, sw.config.NonFiniteFloatPolicy)
+	case CellTypeBool:
+		b, err := toBool(c.Value)
+		if err != nil {
+			return dst, fmt.Errorf("cell %s: %w", ref, err)
+		}
+		return appendBoolCell(dst, ref, c.StyleID, b), nil
+	default:
+		return dst, fmt.Errorf("cell %s: unknown cell type %d", ref, c.Type)
+	}
+}
+
+// formatText returns the text form of a value stored with CellTypeString
+func formatText(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// maxExactInt is the largest magnitude up to which every integer is exactly
+// representable as a float64, and so as an Excel number
+const maxExactInt = 1 << 53
+
+// errInexactNumber is returned for integers that would lose precision as a
+// number; store them with CellTypeString instead
+var errInexactNumber = errors.New("integer is too large to store as a number without losing precision")
+
+// toNumber converts a value stored with CellTypeNumber to a float and the
+// bit size to format it with
+func toNumber(value interface{}) (float64, int, error) {
+	switch v := value.(type) {
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, 0, fmt.Errorf("cannot store %q as number", v)
+		}
+		return f, 64, nil
+	case int:
+		return intToNumber(int64(v))
+	case int8:
+		return float64(v), 64, nil
+	case int16:
+		return float64(v), 64, nil
+	case int32:
+		return float64(v), 64, nil
+	case int64:
+		return intToNumber(v)
+	case uint:
+		return uintToNumber(uint64(v))
+	case uint8:
+		return float64(v), 64, nil
+	case uint16:
+		return float64(v), 64, nil
+	case uint32:
+		return float64(v), 64, nil
+	case uint64:
+		return uintToNumber(v)
+	case float32:
+		return float64(v), 32, nil
+	case float64:
+		return v, 64, nil
+	case bool:
+		if v {
+			return 1, 64, nil
+		}
+		return 0, 64, nil
+	default:
+		return 0, 0, fmt.Errorf("cannot store %T as number", value)
+	}
+}
+
+// intToNumber converts an integer to a float, if it is exactly representable
+func intToNumber(v int64) (float64, int, error) {
+	if v > maxExactInt || v < -maxExactInt {
+		return 0, 0, fmt.Errorf("cannot store %d as number: %w", v, errInexactNumber)
+	}
+	return float64(v), 64, nil
+}
+
+// uintToNumber converts an unsigned integer to a float, if it is exactly
+// representable
+func uintToNumber(v uint64) (float64, int, error) {
+	if v > maxExactInt {
+		return 0, 0, fmt.Errorf("cannot store %d as number: %w", v, errInexactNumber)
+	}
+	return float64(v), 64, nil
+}
+
+// toBool converts a value stored with CellTypeBool to a boolean
+func toBool(value interface{}) (bool, error) {
+	switch v := value.(type) {
+	case bool:
+		return v, nil
+	case string:
+		b, err := strconv.ParseBool(strings.TrimSpace(v))
+		if err != nil {
+			return false, fmt.Errorf("cannot store %q as boolean", v)
+		}
+		return b, nil
+	default:
+		f, _, err := toNumber(value)
+		if errors.Is(err, errInexactNumber) {
+			// Too large to be exact, but certainly not zero
+			return true, nil
+		}
+		if err != nil || math.IsNaN(f) {
+			return false, fmt.Errorf("cannot store %T as boolean", value)
+		}
+		return f != 0, nil
+	}
+}
+
+// excelEpoch is day zero of Excel's 1900 date system, accounting for its
+// fictional 29 February 1900
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// secondsPerDay is the length of a day in the Excel date system
+const secondsPerDay = 24 * 60 * 60
+
+// timeToSerial converts a time to an Excel serial date number, using the
+// wall clock time in the time's location. Whole days are counted in seconds
+// rather than as a time.Duration, which only spans about 292 years.
+func timeToSerial(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	secs := wall.Unix() - excelEpoch.Unix()
+	days, rem := secs/secondsPerDay, secs%secondsPerDay
+	if rem < 0 {
+		days--
+		rem += secondsPerDay
+	}
+	fraction := float64(rem*int64(time.Second)+int64(wall.Nanosecond())) / float64(secondsPerDay*time.Second)
+	return float64(days) + fraction
+}
@@ -0,0 +1,135 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestErrorValueCells(t *testing.T) {
+	tests := []struct {
+		value    ErrorValue
+		expected string
+	}{
+		{ErrorNA, `<c r="A1" t="e"><v>#N/A</v></c>`},
+		{ErrorDiv0, `<c r="A1" t="e"><v>#DIV/0!</v></c>`},
+		{ErrorVal, `<c r="A1" t="e"><v>#VALUE!</v></c>`},
+		{ErrorRef, `<c r="A1" t="e"><v>#REF!</v></c>`},
+		{ErrorName, `<c r="A1" t="e"><v>#NAME?</v></c>`},
+	}
+
+	for _, tt := range tests {
+		got, err := cellXML(newTestSheetWriter(nil), "A1", tt.value)
+		if err != nil {
+			t.Fatalf("Unexpected error for %s: %v", tt.value, err)
+		}
+		if got != tt.expected {
+			t.Errorf("Expected %s, got %s", tt.expected, got)
+		}
+	}
+
+	if _, err := cellXML(newTestSheetWriter(nil), "A1", ErrorValue("#BOGUS!")); err == nil {
+		t.Error("Expected error for unknown error value")
+	}
+}
+
+func TestCellTypeOverrides(t *testing.T) {
+	tests := []struct {
+		name     string
+		cell     Cell
+		expected string
+	}{
+		{"ZIP code stays text", Cell{Value: "01234", Type: CellTypeString}, `<c r="A1" t="inlineStr"><is><t>01234</t></is></c>`},
+		{"Account ID as text", Cell{Value: uint64(12345678901234567890), Type: CellTypeString}, `<t>12345678901234567890</t>`},
+		{"Float as text", Cell{Value: 0.5, Type: CellTypeString}, `<t>0.5</t>`},
+		{"String as number", Cell{Value: " 123.45 ", Type: CellTypeNumber}, `<c r="A1"><v>123.45</v></c>`},
+		{"Bool as number", Cell{Value: true, Type: CellTypeNumber}, `<c r="A1"><v>1</v></c>`},
+		{"String as bool", Cell{Value: "true", Type: CellTypeBool}, `<c r="A1" t="b"><v>1</v></c>`},
+		{"Number as bool", Cell{Value: 0, Type: CellTypeBool}, `<c r="A1" t="b"><v>0</v></c>`},
+		{"Large integer as bool", Cell{Value: uint64(1<<63 + 1), Type: CellTypeBool}, `<c r="A1" t="b"><v>1</v></c>`},
+		{"Largest exact integer", Cell{Value: int64(1 << 53), Type: CellTypeNumber}, `<c r="A1"><v>9007199254740992</v></c>`},
+		{"Smallest exact integer", Cell{Value: int64(-1 << 53), Type: CellTypeNumber}, `<c r="A1"><v>-9007199254740992</v></c>`},
+		{"Auto", Cell{Value: 42}, `<c r="A1"><v>42</v></c>`},
+		{"Nil", Cell{Value: nil, Type: CellTypeNumber}, `<c r="A1"/>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := cellXML(newTestSheetWriter(nil), "A1", tt.cell)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(got, tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+
+	invalid := []Cell{
+		{Value: "12abc", Type: CellTypeNumber},
+		{Value: "maybe", Type: CellTypeBool},
+		{Value: struct{}{}, Type: CellTypeNumber},
+		{Value: int64(1<<53 + 1), Type: CellTypeNumber},
+		{Value: int64(-1<<53 - 1), Type: CellTypeNumber},
+		{Value: uint64(12345678901234567890), Type: CellTypeNumber},
+		{Value: Cell{Value: 1}},
+	}
+	for _, c := range invalid {
+		if _, err := cellXML(newTestSheetWriter(nil), "A1", c); err == nil {
+			t.Errorf("Expected error for %+v", c)
+		}
+	}
+}
+
+func TestWriteErrorAndTypedCells(t *testing.T) {
+	tmpFile := "test_typed_cells.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink)
+
+	if err := writer.StartFile([]interface{}{"Account", "Match"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	if err := writer.WriteRow([]interface{}{Cell{Value: "00042", Type: CellTypeString}, ErrorNA}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheetXML := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := `<row r="2"><c r="A2" t="inlineStr"><is><t>00042</t></is></c><c r="B2" t="e"><v>#N/A</v></c></row>`
+	if !strings.Contains(sheetXML, expected) {
+		t.Errorf("Expected %s in sheet XML, got %s", expected, sheetXML)
+	}
+}
+
+func TestTimeToSerial(t *testing.T) {
+	tests := []struct {
+		time     time.Time
+		expected float64
+	}{
+		{time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC), 0},
+		{time.Date(1900, 3, 1, 0, 0, 0, 0, time.UTC), 61},
+		{time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC), 45306.5},
+		{time.Date(2024, 1, 15, 9, 30, 0, 0, time.FixedZone("UTC+3", 3*3600)), 45306.395833333336},
+		// Beyond the range of a time.Duration from the epoch
+		{time.Date(9999, 12, 31, 0, 0, 0, 0, time.UTC), 2958465},
+		{time.Date(9999, 12, 31, 18, 0, 0, 0, time.UTC), 2958465.75},
+		{time.Date(1600, 1, 1, 6, 0, 0, 0, time.UTC), -109570.75},
+	}
+
+	for _, tt := range tests {
+		if got := timeToSerial(tt.time); got != tt.expected {
+			t.Errorf("timeToSerial(%s) = %v, expected %v", tt.time, got, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ChartType selects the kind of chart
+type ChartType int
+
+const (
+	ChartColumn ChartType = iota
+	ChartBar
+	ChartLine
+	ChartPie
+)
+
+// ChartSeries is one series of values in a chart
+type ChartSeries struct {
+	Name   string
+	Values DataRange
+	Color  string // RGB hex such as "4472C4"; empty uses the default palette
+}
+
+// ChartSpec describes a chart added with AddChart
+type ChartSpec struct {
+	Type       ChartType
+	Title      string
+	Series     []ChartSeries
+	Categories *DataRange // Labels for the category axis or pie slices
+
+	XAxisTitle string
+	YAxisTitle string
+	HideLegend bool
+
+	// Width and Height set the chart size in pixels (default 480x288)
+	Width  int
+	Height int
+}
+
+const (
+	relTypeChart     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/chart"
+	contentTypeChart = "application/vnd.openxmlformats-officedocument.drawingml.chart+xml"
+
+	chartXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<c:chartSpace xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<c:roundedCorners val="0"/>
+<c:chart>
+`
+	chartXMLFooter = `<c:plotVisOnly val="1"/>
+</c:chart>
+</c:chartSpace>`
+
+	defaultChartWidth  = 480
+	defaultChartHeight = 288
+
+	// Axis IDs linking the category and value axes of a chart
+	catAxisID = 100
+	valAxisID = 200
+)
+
+// chartPalette colors series without an explicit color
+var chartPalette = []string{"4472C4", "ED7D31", "A5A5A5", "FFC000", "5B9BD5", "70AD47"}
+
+// sheetChart is a chart waiting for its ranges to be resolved
+type sheetChart struct {
+	spec     ChartSpec
+	row, col int
+}
+
+// validate checks the chart specification
+func (c *ChartSpec) validate() error {
+	if c.Type < ChartColumn || c.Type > ChartPie {
+		return fmt.Errorf("invalid chart type %d", c.Type)
+	}
+	if len(c.Series) == 0 {
+		return fmt.Errorf("chart needs at least one series")
+	}
+	if c.Type == ChartPie && len(c.Series) != 1 {
+		return fmt.Errorf("pie chart needs exactly one series, got %d", len(c.Series))
+	}
+	if c.Width < 0 || c.Height < 0 {
+		return fmt.Errorf("chart size must not be negative")
+	}
+
+	for i, s := range c.Series {
+		if err := s.Values.validateColumn(); err != nil {
+			return fmt.Errorf("series %d: %w", i+1, err)
+		}
+		if s.Color != "" {
+			if _, err := normalizeColor(s.Color); err != nil {
+				return fmt.Errorf("series %d: %w", i+1, err)
+			}
+		}
+	}
+	if c.Categories != nil {
+		if err := c.Categories.validateColumn(); err != nil {
+			return fmt.Errorf("categories: %w", err)
+		}
+	}
+	return nil
+}
+
+// validateColumn checks a range used by a chart, which covers a single column
+func (r *DataRange) validateColumn() error {
+	if err := r.validate(); err != nil {
+		return err
+	}
+	if strings.Contains(r.Column, ":") {
+		return fmt.Errorf("range must cover a single column, got %q", r.Column)
+	}
+	return nil
+}
+
+// AddChart places a chart at a cell of the current sheet, e.g. "H2". The
+// chart part is written when the file is finished, so its ranges can cover
+// rows streamed after the chart was added.
+func (w *Writer) AddChart(cell string, spec ChartSpec) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+
+	row, col, err := parseCellReference(cell)
+	if err != nil {
+		return err
+	}
+	if row >= w.sheetCapacity() {
+		return fmt.Errorf("chart cell %s exceeds the sheet limit of %d rows", cell, w.sheetCapacity())
+	}
+	if err := spec.validate(); err != nil {
+		return fmt.Errorf("invalid chart: %w", err)
+	}
+
+	// Copy the series so later changes by the caller do not affect the chart,
+	// and pin ranges on the chart's own sheet
+	sheet := w.current.sheetIndex + 1
+	spec.Series = append([]ChartSeries(nil), spec.Series...)
+	for i := range spec.Series {
+		if spec.Series[i].Values.Sheet == 0 {
+			spec.Series[i].Values.Sheet = sheet
+		}
+	}
+	if spec.Categories != nil {
+		categories := *spec.Categories
+		if categories.Sheet == 0 {
+			categories.Sheet = sheet
+		}
+		spec.Categories = &categories
+	}
+
+	sw := w.current
+	sw.charts = append(sw.charts, &sheetChart{spec: spec, row: row, col: col})
+	return nil
+}
+
+// writePendingDrawings adds the charts to drawings kept open until the end
+// of the file and writes the drawing and chart parts
+func (w *Writer) writePendingDrawings() error {
+	for _, d := range w.pendingDrawings {
+		var parts []sheetPart
+		for _, chart := range d.charts {
+			chartXML, err := w.generateChartXML(&chart.spec)
+			if err != nil {
+				return fmt.Errorf("chart %d: %w", w.chartCount+1, err)
+			}
+
+			w.chartCount++
+			chartPart := fmt.Sprintf("xl/charts/chart%d.xml", w.chartCount)
+			relID := d.addRelationship(relTypeChart, fmt.Sprintf("../charts/chart%d.xml", w.chartCount))
+			d.shapes++
+			d.anchors.WriteString(chart.generateAnchorXML(d.shapes, relID))
+
+			parts = append(parts, xmlPart(chartPart, contentTypeChart, chartXML))
+		}
+
+		if err := w.writeParts(append(parts, d.parts()...)); err != nil {
+			return err
+		}
+	}
+	w.pendingDrawings = nil
+	return nil
+}
+
+// generateAnchorXML generates the drawing anchor holding the chart
+func (c *sheetChart) generateAnchorXML(id int, relID string) string {
+	width, height := c.spec.Width, c.spec.Height
+	if width == 0 {
+		width = defaultChartWidth
+	}
+	if height == 0 {
+		height = defaultChartHeight
+	}
+
+	var anchor strings.Builder
+	anchor.WriteString(anchorStart(AnchorTwoCell, c.row, c.col, 0, 0, width, height))
+	anchor.WriteString(fmt.Sprintf(`<xdr:graphicFrame macro=""><xdr:nvGraphicFramePr><xdr:cNvPr id="%d" name="Chart %d"/><xdr:cNvGraphicFramePr/></xdr:nvGraphicFramePr>`, id+1, id))
+	anchor.WriteString(`<xdr:xfrm><a:off x="0" y="0"/><a:ext cx="0" cy="0"/></xdr:xfrm>`)
+	anchor.WriteString(fmt.Sprintf(`<a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/chart"><c:chart xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" r:id="%s"/></a:graphicData></a:graphic></xdr:graphicFrame>`, relID))
+	anchor.WriteString(anchorEnd(AnchorTwoCell))
+	return anchor.String()
+}
+
+// generateChartXML generates the chart part with resolved ranges
+func (w *Writer) generateChartXML(spec *ChartSpec) (string, error) {
+	var categories string
+	if spec.Categories != nil {
+		ref, err := w.resolveRange(*spec.Categories)
+		if err != nil {
+			return "", fmt.Errorf("categories: %w", err)
+		}
+		categories = fmt.Sprintf(`<c:cat><c:strRef><c:f>%s</c:f></c:strRef></c:cat>`, escapeXML(ref))
+	}
+
+	var series strings.Builder
+	for i, s := range spec.Series {
+		ref, err := w.resolveRange(s.Values)
+		if err != nil {
+			return "", fmt.Errorf("series %d: %w", i+1, err)
+		}
+
+		series.WriteString(fmt.Sprintf(`<c:ser><c:idx val="%d"/><c:order val="%d"/>`, i, i))
+		if s.Name != "" {
+			series.WriteString(fmt.Sprintf(`<c:tx><c:v>%s</c:v></c:tx>`, escapeXML(s.Name)))
+		}
+
+		color := chartPalette[i%len(chartPalette)]
+		if s.Color != "" {
+			argb, _ := normalizeColor(s.Color)
+			color = argb[2:]
+		}
+		switch spec.Type {
+		case ChartLine:
+			series.WriteString(fmt.Sprintf(`<c:spPr><a:ln w="28575"><a:solidFill><a:srgbClr val="%s"/></a:solidFill></a:ln></c:spPr><c:marker><c:symbol val="none"/></c:marker>`, color))
+		case ChartPie:
+			// Slices take their colors from varyColors
+		default:
+			series.WriteString(fmt.Sprintf(`<c:spPr><a:solidFill><a:srgbClr val="%s"/></a:solidFill></c:spPr><c:invertIfNegative val="0"/>`, color))
+		}
+
+		series.WriteString(categories)
+		series.WriteString(fmt.Sprintf(`<c:val><c:numRef><c:f>%s</c:f></c:numRef></c:val>`, escapeXML(ref)))
+		if spec.Type == ChartLine {
+			series.WriteString(`<c:smooth val="0"/>`)
+		}
+		series.WriteString("</c:ser>\n")
+	}
+
+	var chart strings.Builder
+	chart.WriteString(chartXMLHeader)
+	if spec.Title != "" {
+		chart.WriteString(chartTitleXML(spec.Title))
+		chart.WriteString(`<c:autoTitleDeleted val="0"/>`)
+	} else {
+		chart.WriteString(`<c:autoTitleDeleted val="1"/>`)
+	}
+	chart.WriteString("\n<c:plotArea><c:layout/>\n")
+
+	axisIDs := fmt.Sprintf(`<c:axId val="%d"/><c:axId val="%d"/>`, catAxisID, valAxisID)
+	switch spec.Type {
+	case ChartColumn, ChartBar:
+		barDir, catPos, valPos := "col", "b", "l"
+		if spec.Type == ChartBar {
+			barDir, catPos, valPos = "bar", "l", "b"
+		}
+		chart.WriteString(fmt.Sprintf(`<c:barChart><c:barDir val="%s"/><c:grouping val="clustered"/><c:varyColors val="0"/>
+`, barDir))
+		chart.WriteString(series.String())
+		chart.WriteString(`<c:gapWidth val="150"/>` + axisIDs + "</c:barChart>\n")
+		chart.WriteString(generateAxesXML(spec, catPos, valPos))
+	case ChartLine:
+		chart.WriteString(`<c:lineChart><c:grouping val="standard"/><c:varyColors val="0"/>
+`)
+		chart.WriteString(series.String())
+		chart.WriteString(`<c:marker val="1"/>` + axisIDs + "</c:lineChart>\n")
+		chart.WriteString(generateAxesXML(spec, "b", "l"))
+	case ChartPie:
+		chart.WriteString(`<c:pieChart><c:varyColors val="1"/>
+`)
+		chart.WriteString(series.String())
+		chart.WriteString(`<c:firstSliceAng val="0"/></c:pieChart>` + "\n")
+	}
+	chart.WriteString("</c:plotArea>\n")
+
+	if !spec.HideLegend {
+		chart.WriteString(`<c:legend><c:legendPos val="r"/><c:overlay val="0"/></c:legend>` + "\n")
+	}
+	chart.WriteString(chartXMLFooter)
+	return chart.String(), nil
+}
+
+// generateAxesXML generates the category and value axes of a bar or line chart
+func generateAxesXML(spec *ChartSpec, catPos, valPos string) string {
+	var axes strings.Builder
+
+	axes.WriteString(fmt.Sprintf(`<c:catAx><c:axId val="%d"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="%s"/>`, catAxisID, catPos))
+	if spec.XAxisTitle != "" {
+		axes.WriteString(chartTitleXML(spec.XAxisTitle))
+	}
+	axes.WriteString(fmt.Sprintf(`<c:numFmt formatCode="General" sourceLinked="1"/><c:tickLblPos val="nextTo"/><c:crossAx val="%d"/><c:crosses val="autoZero"/><c:auto val="1"/><c:lblAlgn val="ctr"/><c:lblOffset val="100"/></c:catAx>`, valAxisID))
+	axes.WriteString("\n")
+
+	axes.WriteString(fmt.Sprintf(`<c:valAx><c:axId val="%d"/><c:scaling><c:orientation val="minMax"/></c:scaling><c:delete val="0"/><c:axPos val="%s"/><c:majorGridlines/>`, valAxisID, valPos))
+	if spec.YAxisTitle != "" {
+		axes.WriteString(chartTitleXML(spec.YAxisTitle))
+	}
+	axes.WriteString(fmt.Sprintf(`<c:numFmt formatCode="General" sourceLinked="1"/><c:tickLblPos val="nextTo"/><c:crossAx val="%d"/><c:crosses val="autoZero"/><c:crossBetween val="between"/></c:valAx>`, catAxisID))
+	axes.WriteString("\n")
+
+	return axes.String()
+}
+
+// chartTitleXML generates a chart or axis title
+func chartTitleXML(title string) string {
+	return fmt.Sprintf(`<c:title><c:tx><c:rich><a:bodyPr/><a:p><a:r><a:t>%s</a:t></a:r></a:p></c:rich></c:tx><c:overlay val="0"/></c:title>`, escapeXML(title))
+}
@@ -0,0 +1,180 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestChartValidation(t *testing.T) {
+	tmpFile := "test_chart_validation.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink, DefaultConfig())
+	if err := writer.StartFile([]interface{}{"Month", "Revenue", "Cost"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	twoSeries := []ChartSeries{{Values: DataRange{Column: "B"}}, {Values: DataRange{Column: "C"}}}
+	invalid := []ChartSpec{
+		{Type: ChartPie, Series: twoSeries},
+		{Type: ChartLine},
+		{Series: []ChartSeries{{Values: DataRange{Column: "A:B"}}}},
+		{Series: []ChartSeries{{Values: DataRange{Column: "B", FromRow: 5, ToRow: 2}}}},
+		{Series: []ChartSeries{{Values: DataRange{Column: "B"}, Color: "blue"}}},
+		{Type: ChartType(9), Series: twoSeries},
+	}
+	for i, spec := range invalid {
+		if err := writer.AddChart("E2", spec); err == nil {
+			t.Errorf("Expected error for invalid chart %d", i)
+		}
+	}
+
+	// Ranges on sheets that were never created fail when the file is finished
+	if err := writer.AddChart("E2", ChartSpec{Series: []ChartSeries{{Values: DataRange{Sheet: 9, Column: "B"}}}}); err != nil {
+		t.Fatalf("Failed to add chart: %v", err)
+	}
+	if _, err := writer.FinishFile(); err == nil {
+		t.Fatal("Expected error for chart range on a missing sheet")
+	}
+}
+
+func TestChartsTotalsRow(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	config.SheetOptions.Table = &Table{TotalsRow: true}
+	writer := NewWriter(discardSink{}, config)
+	if err := writer.StartFile([]interface{}{"ID"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	// The last row of the sheet is kept for the totals row
+	spec := ChartSpec{Series: []ChartSeries{{Values: DataRange{Column: "A"}}}}
+	if err := writer.AddChart("B3", spec); err == nil {
+		t.Error("Expected error for chart on the totals row")
+	}
+	if err := writer.AddChart("B2", spec); err != nil {
+		t.Errorf("Failed to add chart: %v", err)
+	}
+}
+
+func TestChartsResolveRanges(t *testing.T) {
+	tmpFile := "test_charts_ranges.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 5
+	config.SheetNamePrefix = "Sales's "
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"Month", "Revenue", "Cost"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	if err := writer.AddChart("E2", ChartSpec{
+		Type:       ChartColumn,
+		Title:      "Revenue & Cost",
+		Categories: &DataRange{Column: "A"},
+		Series: []ChartSeries{
+			{Name: "Revenue", Values: DataRange{Column: "B"}},
+			{Name: "Cost", Values: DataRange{Column: "c"}, Color: "#C00000"},
+		},
+		YAxisTitle: "USD",
+	}); err != nil {
+		t.Fatalf("Failed to add chart: %v", err)
+	}
+
+	for i := 1; i <= 6; i++ {
+		if err := writer.WriteRow([]interface{}{i, i * 100, i * 60}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+
+	if err := writer.AddChart("A3", ChartSpec{
+		Type:       ChartPie,
+		Categories: &DataRange{Sheet: 1, Column: "A", FromRow: 3},
+		Series:     []ChartSeries{{Values: DataRange{Sheet: 1, Column: "B", FromRow: 3}}},
+		HideLegend: true,
+	}); err != nil {
+		t.Fatalf("Failed to add chart: %v", err)
+	}
+	if err := writer.AddChart("D3", ChartSpec{Type: ChartLine, Series: []ChartSeries{{Values: DataRange{Column: "B", ToRow: 10}}}}); err != nil {
+		t.Fatalf("Failed to add chart: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheet1, `<drawing r:id="rId1"/>`) {
+		t.Errorf("Expected drawing in sheet 1, got %s", sheet1)
+	}
+
+	chart1 := readZipEntry(t, tmpFile, "xl/charts/chart1.xml")
+	expected := []string{
+		`<a:t>Revenue &amp; Cost</a:t>`,
+		`<c:barDir val="col"/>`,
+		`<c:tx><c:v>Revenue</c:v></c:tx>`,
+		// Sheet 1 holds the header and 4 data rows
+		`<c:cat><c:strRef><c:f>&#39;Sales&#39;&#39;s 1&#39;!$A$2:$A$5</c:f></c:strRef></c:cat>`,
+		`<c:val><c:numRef><c:f>&#39;Sales&#39;&#39;s 1&#39;!$B$2:$B$5</c:f></c:numRef></c:val>`,
+		`<a:srgbClr val="C00000"/>`,
+		`$C$2:$C$5`,
+		`<a:t>USD</a:t>`,
+		`<c:legend>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(chart1, e) {
+			t.Errorf("Expected %s in chart 1, got %s", e, chart1)
+		}
+	}
+
+	chart2 := readZipEntry(t, tmpFile, "xl/charts/chart2.xml")
+	for _, e := range []string{`<c:pieChart><c:varyColors val="1"/>`, `$B$3:$B$5`, `<c:autoTitleDeleted val="1"/>`} {
+		if !strings.Contains(chart2, e) {
+			t.Errorf("Expected %s in chart 2, got %s", e, chart2)
+		}
+	}
+	if strings.Contains(chart2, "<c:legend>") {
+		t.Error("Expected hidden legend in chart 2")
+	}
+
+	chart3 := readZipEntry(t, tmpFile, "xl/charts/chart3.xml")
+	for _, e := range []string{`<c:lineChart>`, `&#39;Sales&#39;&#39;s 2&#39;!$B$1:$B$10`} {
+		if !strings.Contains(chart3, e) {
+			t.Errorf("Expected %s in chart 3, got %s", e, chart3)
+		}
+	}
+
+	drawing2 := readZipEntry(t, tmpFile, "xl/drawings/drawing2.xml")
+	expectedDrawing := []string{
+		// 480x288 px at A3 ends at column H, row 17 + 8px
+		`<xdr:from><xdr:col>0</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>2</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from><xdr:to><xdr:col>7</xdr:col><xdr:colOff>304800</xdr:colOff><xdr:row>16</xdr:row><xdr:rowOff>76200</xdr:rowOff></xdr:to>`,
+		`<c:chart xmlns:c="http://schemas.openxmlformats.org/drawingml/2006/chart" r:id="rId2"/>`,
+	}
+	for _, e := range expectedDrawing {
+		if !strings.Contains(drawing2, e) {
+			t.Errorf("Expected %s in drawing 2, got %s", e, drawing2)
+		}
+	}
+
+	drawingRels := readZipEntry(t, tmpFile, "xl/drawings/_rels/drawing2.xml.rels")
+	if !strings.Contains(drawingRels, `Target="../charts/chart3.xml"`) {
+		t.Errorf("Expected chart relationship, got %s", drawingRels)
+	}
+
+	contentTypes := readZipEntry(t, tmpFile, "[Content_Types].xml")
+	if !strings.Contains(contentTypes, `<Override PartName="/xl/charts/chart3.xml" ContentType="application/vnd.openxmlformats-officedocument.drawingml.chart+xml"/>`) {
+		t.Errorf("Expected chart content type, got %s", contentTypes)
+	}
+}
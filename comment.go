@@ -0,0 +1,159 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+const (
+	relTypeComments   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/comments"
+	relTypeVMLDrawing = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/vmlDrawing"
+
+	contentTypeComments   = "application/vnd.openxmlformats-officedocument.spreadsheetml.comments+xml"
+	contentTypeVMLDrawing = "application/vnd.openxmlformats-officedocument.vmlDrawing"
+
+	commentsXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<comments xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+`
+
+	vmlShapeType = `<v:shapetype id="_x0000_t202" coordsize="21600,21600" o:spt="202" path="m,l,21600r21600,l21600,xe">
+<v:stroke joinstyle="miter"/><v:path gradientshapeok="t" o:connecttype="rect"/>
+</v:shapetype>
+`
+
+	// vmlShapeBlockSize is the number of shape IDs covered by each VML idmap entry
+	vmlShapeBlockSize = 1024
+)
+
+// cellKey packs a zero-based cell position into an integer, as Excel columns
+// fit in 14 bits
+func cellKey(row, col int) uint64 {
+	return uint64(row)<<14 | uint64(col)
+}
+
+// AddComment attaches a comment (note) by author to a cell of the current
+// sheet, e.g. "C12". Each cell can hold one comment.
+func (w *Writer) AddComment(ref, author, text string) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+
+	row, col, err := parseCellReference(ref)
+	if err != nil {
+		return err
+	}
+	sw := w.current
+	if row >= w.sheetCapacity() {
+		return fmt.Errorf("comment cell %s exceeds the sheet limit of %d rows", ref, w.sheetCapacity())
+	}
+	if _, ok := sw.commentCells[cellKey(row, col)]; ok {
+		return fmt.Errorf("cell %s already has a comment", ref)
+	}
+
+	if w.nextShapeID == 0 {
+		// Shape IDs start at the first ID of the first idmap block
+		w.nextShapeID = vmlShapeBlockSize + 1
+	}
+	shapeID := w.nextShapeID
+	w.nextShapeID++
+
+	return sw.addComment(row, col, author, text, shapeID)
+}
+
+// addComment records a comment and the VML shape displaying it
+func (sw *sheetWriter) addComment(row, col int, author, text string, shapeID int) error {
+	if sw.comments == nil {
+		sw.comments = newSpillBuffer(sw.config.SpillThreshold, sw.config.TempDir)
+		sw.commentShapes = newSpillBuffer(sw.config.SpillThreshold, sw.config.TempDir)
+		sw.commentAuthors = make(map[string]int)
+		sw.commentCells = make(map[uint64]struct{})
+		sw.firstShapeID = shapeID
+	}
+	sw.lastShapeID = shapeID
+	sw.commentCells[cellKey(row, col)] = struct{}{}
+
+	authorID, ok := sw.commentAuthors[author]
+	if !ok {
+		authorID = len(sw.commentAuthorIDs)
+		sw.commentAuthors[author] = authorID
+		sw.commentAuthorIDs = append(sw.commentAuthorIDs, author)
+	}
+
+	comment := fmt.Sprintf(`<comment ref="%s" authorId="%d"><text><r>%s</r></text></comment>
+`, cellReference(row, col), authorID, textElement(text))
+	if _, err := sw.comments.WriteString(comment); err != nil {
+		return fmt.Errorf("failed to record comment: %w", err)
+	}
+
+	// The note box is anchored to the right of the cell, like Excel does
+	shape := fmt.Sprintf(`<v:shape id="_x0000_s%d" type="#_x0000_t202" style="position:absolute;margin-left:59.25pt;margin-top:1.5pt;width:108pt;height:59.25pt;z-index:%d;visibility:hidden" fillcolor="#ffffe1" o:insetmode="auto">
+<v:fill color2="#ffffe1"/><v:shadow on="t" color="black" obscured="t"/><v:path o:connecttype="none"/>
+<v:textbox style="mso-direction-alt:auto"><div style="text-align:left"></div></v:textbox>
+<x:ClientData ObjectType="Note"><x:MoveWithCells/><x:SizeWithCells/><x:Anchor>%d, 15, %d, 2, %d, 15, %d, 16</x:Anchor><x:AutoFill>False</x:AutoFill><x:Row>%d</x:Row><x:Column>%d</x:Column></x:ClientData>
+</v:shape>
+`, shapeID, shapeID-sw.firstShapeID+1, col+1, max(row-1, 0), col+3, max(row-1, 0)+4, row, col)
+	if _, err := sw.commentShapes.WriteString(shape); err != nil {
+		return fmt.Errorf("failed to record comment shape: %w", err)
+	}
+
+	return nil
+}
+
+// writeLegacyDrawing writes the sheet's <legacyDrawing> element and returns
+// the comments and VML drawing parts it references
+func (w *Writer) writeLegacyDrawing(sw *sheetWriter) ([]sheetPart, error) {
+	w.commentsCount++
+	commentsPart := fmt.Sprintf("xl/comments%d.xml", w.commentsCount)
+	vmlPart := fmt.Sprintf("xl/drawings/vmlDrawing%d.vml", w.commentsCount)
+
+	if _, err := sw.addRelationship(relTypeComments, "../"+strings.TrimPrefix(commentsPart, "xl/"), false); err != nil {
+		return nil, err
+	}
+	vmlID, err := sw.addRelationship(relTypeVMLDrawing, "../"+strings.TrimPrefix(vmlPart, "xl/"), false)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := fmt.Fprintf(sw.writer, `<legacyDrawing r:id="%s"/>
+`, vmlID); err != nil {
+		return nil, err
+	}
+
+	// The idmap lists every block of 1024 shape IDs used by the drawing
+	var blocks []string
+	for block := sw.firstShapeID / vmlShapeBlockSize; block <= sw.lastShapeID/vmlShapeBlockSize; block++ {
+		blocks = append(blocks, fmt.Sprintf("%d", block))
+	}
+
+	comments := sw.comments
+	shapes := sw.commentShapes
+	authors := sw.commentAuthorIDs
+
+	return []sheetPart{
+		{
+			name:        commentsPart,
+			contentType: contentTypeComments,
+			write: func(pw io.Writer) error {
+				var header strings.Builder
+				header.WriteString(commentsXMLHeader)
+				header.WriteString(`<authors>`)
+				for _, author := range authors {
+					header.WriteString(fmt.Sprintf(`<author>%s</author>`, escapeXML(author)))
+				}
+				header.WriteString("</authors>\n<commentList>\n")
+				return writeWrapped(pw, header.String(), comments, "</commentList>\n</comments>")
+			},
+		},
+		{
+			name:        vmlPart,
+			contentType: contentTypeVMLDrawing,
+			write: func(pw io.Writer) error {
+				header := fmt.Sprintf(`<xml xmlns:v="urn:schemas-microsoft-com:vml" xmlns:o="urn:schemas-microsoft-com:office:office" xmlns:x="urn:schemas-microsoft-com:office:excel">
+<o:shapelayout v:ext="edit"><o:idmap v:ext="edit" data="%s"/></o:shapelayout>
+%s`, strings.Join(blocks, ","), vmlShapeType)
+				return writeWrapped(pw, header, shapes, "</xml>")
+			},
+		},
+	}, nil
+}
@@ -0,0 +1,224 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestComments(t *testing.T) {
+	tmpFile := "test_comments.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID", "Value"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	if err := writer.WriteRow([]interface{}{1, -5}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+	if err := writer.AddComment("B2", "Data Quality", "Negative value <check>"); err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+	if err := writer.AddComment("A1", "Exporter", "Generated "); err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+	if err := writer.AddComment("B1", "Data Quality", "Second by same author"); err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+
+	if err := writer.AddComment("B4", "x", "beyond the sheet"); err == nil {
+		t.Error("Expected error for comment beyond MaxRowsPerSheet")
+	}
+	if err := writer.AddComment("2B", "x", "bad ref"); err == nil {
+		t.Error("Expected error for invalid cell reference")
+	}
+	if err := writer.AddComment("B2", "x", "second comment"); err == nil || !strings.Contains(err.Error(), "already has a comment") {
+		t.Errorf("Expected error for a second comment on a cell, got %v", err)
+	}
+
+	// Roll over to a second sheet and comment there
+	for i := 2; i <= 3; i++ {
+		if err := writer.WriteRow([]interface{}{i, i}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+	if err := writer.AddComment("A1", "Exporter", "Continued"); err != nil {
+		t.Fatalf("Failed to add comment: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheet1, `<legacyDrawing r:id="rId2"/>
+</worksheet>`) {
+		t.Errorf("Expected legacy drawing in sheet 1, got %s", sheet1)
+	}
+
+	comments := readZipEntry(t, tmpFile, "xl/comments1.xml")
+	expected := []string{
+		`<authors><author>Data Quality</author><author>Exporter</author></authors>`,
+		`<comment ref="B2" authorId="0"><text><r><t>Negative value &lt;check&gt;</t></r></text></comment>`,
+		`<comment ref="A1" authorId="1"><text><r><t xml:space="preserve">Generated </t></r></text></comment>`,
+		`<comment ref="B1" authorId="0">`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(comments, e) {
+			t.Errorf("Expected %s in comments, got %s", e, comments)
+		}
+	}
+
+	vml := readZipEntry(t, tmpFile, "xl/drawings/vmlDrawing1.vml")
+	expectedVML := []string{
+		`<o:idmap v:ext="edit" data="1"/>`,
+		`<v:shape id="_x0000_s1025"`,
+		`<x:Row>1</x:Row><x:Column>1</x:Column>`,
+		`<v:shape id="_x0000_s1027"`,
+	}
+	for _, e := range expectedVML {
+		if !strings.Contains(vml, e) {
+			t.Errorf("Expected %s in VML drawing, got %s", e, vml)
+		}
+	}
+
+	rels := readZipEntry(t, tmpFile, "xl/worksheets/_rels/sheet1.xml.rels")
+	for _, e := range []string{`Target="../comments1.xml"`, `Target="../drawings/vmlDrawing1.vml"`} {
+		if !strings.Contains(rels, e) {
+			t.Errorf("Expected %s in sheet rels, got %s", e, rels)
+		}
+	}
+
+	if !strings.Contains(readZipEntry(t, tmpFile, "xl/comments2.xml"), `<comment ref="A1" authorId="0">`) {
+		t.Error("Expected comment in second comments part")
+	}
+	if !strings.Contains(readZipEntry(t, tmpFile, "xl/drawings/vmlDrawing2.vml"), `<v:shape id="_x0000_s1028"`) {
+		t.Error("Expected shape IDs to continue across sheets")
+	}
+
+	contentTypes := readZipEntry(t, tmpFile, "[Content_Types].xml")
+	for _, e := range []string{
+		`<Override PartName="/xl/comments1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.comments+xml"/>`,
+		`<Override PartName="/xl/drawings/vmlDrawing2.vml" ContentType="application/vnd.openxmlformats-officedocument.vmlDrawing"/>`,
+	} {
+		if !strings.Contains(contentTypes, e) {
+			t.Errorf("Expected %s in content types, got %s", e, contentTypes)
+		}
+	}
+}
+
+func TestCommentsTotalsRow(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	config.SheetOptions.Table = &Table{TotalsRow: true}
+	writer := NewWriter(discardSink{}, config)
+	if err := writer.StartFile([]interface{}{"ID"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	// The last row of the sheet is kept for the totals row
+	if err := writer.AddComment("A3", "QA", "on the totals row"); err == nil {
+		t.Error("Expected error for comment on the totals row")
+	}
+	if err := writer.AddComment("A2", "QA", "on a data row"); err != nil {
+		t.Errorf("Failed to add comment: %v", err)
+	}
+}
+
+func TestCommentsSpillToDisk(t *testing.T) {
+	tmpFile := "test_comments_spill.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	tempDir := t.TempDir()
+	config := DefaultConfig()
+	config.SpillThreshold = 1024
+	config.TempDir = tempDir
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	for i := 1; i <= 1500; i++ {
+		if err := writer.WriteRow([]interface{}{i}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+		if err := writer.AddComment(fmt.Sprintf("A%d", i), "QA", fmt.Sprintf("Note %d", i)); err != nil {
+			t.Fatalf("Failed to add comment: %v", err)
+		}
+	}
+
+	if entries, _ := os.ReadDir(tempDir); len(entries) == 0 {
+		t.Error("Expected comments to be spilled to disk")
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected spill files to be removed, found %d", len(entries))
+	}
+
+	if got := strings.Count(readZipEntry(t, tmpFile, "xl/comments1.xml"), "<comment "); got != 1500 {
+		t.Errorf("Expected 1500 comments, got %d", got)
+	}
+
+	// 1500 shapes starting at 1025 span two idmap blocks
+	vml := readZipEntry(t, tmpFile, "xl/drawings/vmlDrawing1.vml")
+	if !strings.Contains(vml, `<o:idmap v:ext="edit" data="1,2"/>`) {
+		t.Errorf("Expected idmap covering two blocks")
+	}
+	if got := strings.Count(vml, "<v:shape "); got != 1500 {
+		t.Errorf("Expected 1500 shapes, got %d", got)
+	}
+}
+
+func TestCommentsMemory(t *testing.T) {
+	config := DefaultConfig()
+	config.SpillThreshold = 4096
+	config.TempDir = t.TempDir()
+	writer := NewWriter(discardSink{}, config)
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	const comments = 200000
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	for i := 1; i <= comments; i++ {
+		if err := writer.AddComment(fmt.Sprintf("B%d", i), "QA", "Note"); err != nil {
+			t.Fatalf("Failed to add comment: %v", err)
+		}
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&after)
+
+	// The comments spill to disk; only a packed position per cell is kept to
+	// detect duplicates
+	if growth := int64(after.HeapAlloc) - int64(before.HeapAlloc); growth > comments*32 {
+		t.Errorf("Expected at most %d bytes of heap growth, got %d", comments*32, growth)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+}
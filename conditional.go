@@ -0,0 +1,234 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ConditionalFormatType is the kind of conditional formatting rule
+type ConditionalFormatType string
+
+// Conditional formatting rule types
+const (
+	// ConditionalCellIs formats cells whose value compares to Formula1/Formula2
+	ConditionalCellIs ConditionalFormatType = "cellIs"
+	// ConditionalExpression formats cells for which Formula1 is true
+	ConditionalExpression ConditionalFormatType = "expression"
+	// ConditionalColorScale shades cells on a two or three color gradient
+	ConditionalColorScale ConditionalFormatType = "colorScale"
+	// ConditionalDataBar draws a bar proportional to the cell value
+	ConditionalDataBar ConditionalFormatType = "dataBar"
+	// ConditionalIconSet shows an icon depending on the cell value's percentile
+	ConditionalIconSet ConditionalFormatType = "iconSet"
+)
+
+// ConditionalOperator compares a cell value in a ConditionalCellIs rule
+type ConditionalOperator string
+
+// Conditional formatting operators
+const (
+	ConditionalBetween            ConditionalOperator = "between"
+	ConditionalNotBetween         ConditionalOperator = "notBetween"
+	ConditionalEqual              ConditionalOperator = "equal"
+	ConditionalNotEqual           ConditionalOperator = "notEqual"
+	ConditionalGreaterThan        ConditionalOperator = "greaterThan"
+	ConditionalLessThan           ConditionalOperator = "lessThan"
+	ConditionalGreaterThanOrEqual ConditionalOperator = "greaterThanOrEqual"
+	ConditionalLessThanOrEqual    ConditionalOperator = "lessThanOrEqual"
+)
+
+// iconSetSizes maps the icon sets Excel supports to their number of icons
+var iconSetSizes = map[string]int{
+	"3Arrows": 3, "3ArrowsGray": 3, "3Flags": 3, "3TrafficLights1": 3, "3TrafficLights2": 3,
+	"3Signs": 3, "3Symbols": 3, "3Symbols2": 3,
+	"4Arrows": 4, "4ArrowsGray": 4, "4RedToBlack": 4, "4Rating": 4, "4TrafficLights": 4,
+	"5Arrows": 5, "5ArrowsGray": 5, "5Rating": 5, "5Quarters": 5,
+}
+
+// ConditionalFormat is a conditional formatting rule applied to one or more
+// columns, covering the data rows of each sheet (excluding the header row)
+type ConditionalFormat struct {
+	// Columns is the column or column span the rule applies to, e.g. "C" or "C:E"
+	Columns string
+
+	Type     ConditionalFormatType
+	Operator ConditionalOperator
+
+	// Formula1 and Formula2 are the bounds of a cellIs rule (numbers, time.Time
+	// values or formulas). Formula1 is the formula of an expression rule,
+	// written relative to the first data row, e.g. "$D2<TODAY()".
+	Formula1 interface{}
+	Formula2 interface{}
+
+	// Format is applied to matching cells of cellIs and expression rules
+	Format *Style
+
+	// MinColor, MidColor and MaxColor are the colors of a color scale;
+	// MidColor is optional
+	MinColor string
+	MidColor string
+	MaxColor string
+
+	// BarColor is the color of a data bar
+	BarColor string
+
+	// IconSet is the icon set name (default: "3TrafficLights1")
+	IconSet      string
+	ReverseIcons bool
+
+	// StopIfTrue skips rules with a lower priority when this rule matches
+	StopIfTrue bool
+}
+
+// validate checks the rule's definition
+func (cf *ConditionalFormat) validate() error {
+	if _, _, err := parseColumnSpan(cf.Columns); err != nil {
+		return err
+	}
+
+	switch cf.Type {
+	case ConditionalCellIs:
+		switch cf.Operator {
+		case ConditionalBetween, ConditionalNotBetween:
+			if cf.Formula2 == nil {
+				return fmt.Errorf("operator %s requires Formula2", cf.Operator)
+			}
+		case ConditionalEqual, ConditionalNotEqual, ConditionalGreaterThan, ConditionalLessThan,
+			ConditionalGreaterThanOrEqual, ConditionalLessThanOrEqual:
+		default:
+			return fmt.Errorf("unknown conditional operator %q", cf.Operator)
+		}
+		fallthrough
+	case ConditionalExpression:
+		if cf.Formula1 == nil {
+			return fmt.Errorf("%s rule requires Formula1", cf.Type)
+		}
+		if cf.Format == nil {
+			return fmt.Errorf("%s rule requires Format", cf.Type)
+		}
+		for _, f := range []interface{}{cf.Formula1, cf.Formula2} {
+			if _, err := validationFormula(f); err != nil {
+				return err
+			}
+		}
+	case ConditionalColorScale:
+		if cf.MinColor == "" || cf.MaxColor == "" {
+			return fmt.Errorf("color scale requires MinColor and MaxColor")
+		}
+		for _, c := range []string{cf.MinColor, cf.MidColor, cf.MaxColor} {
+			if c == "" {
+				continue
+			}
+			if _, err := normalizeColor(c); err != nil {
+				return err
+			}
+		}
+	case ConditionalDataBar:
+		if _, err := normalizeColor(cf.BarColor); err != nil {
+			return err
+		}
+	case ConditionalIconSet:
+		if _, ok := iconSetSizes[cf.iconSet()]; !ok {
+			return fmt.Errorf("unknown icon set %q", cf.IconSet)
+		}
+	default:
+		return fmt.Errorf("unknown conditional format type %q", cf.Type)
+	}
+
+	return nil
+}
+
+// iconSet returns the rule's icon set name, defaulting to traffic lights
+func (cf *ConditionalFormat) iconSet() string {
+	if cf.IconSet == "" {
+		return "3TrafficLights1"
+	}
+	return cf.IconSet
+}
+
+// generateXML generates a <conditionalFormatting> element covering the given
+// rows. dxfID is the differential format of cellIs and expression rules.
+func (cf *ConditionalFormat) generateXML(firstRow, lastRow, priority, dxfID int) string {
+	firstCol, lastCol, _ := parseColumnSpan(cf.Columns)
+	sqref := cellRange{fromRow: firstRow, fromCol: firstCol, toRow: lastRow, toCol: lastCol}
+
+	var rule strings.Builder
+	rule.WriteString(fmt.Sprintf(`<conditionalFormatting sqref="%s"><cfRule type="%s"`, sqref, cf.Type))
+	if cf.Type == ConditionalCellIs || cf.Type == ConditionalExpression {
+		rule.WriteString(fmt.Sprintf(` dxfId="%d"`, dxfID))
+	}
+	rule.WriteString(fmt.Sprintf(` priority="%d"`, priority))
+	if cf.StopIfTrue {
+		rule.WriteString(` stopIfTrue="1"`)
+	}
+	if cf.Type == ConditionalCellIs {
+		rule.WriteString(fmt.Sprintf(` operator="%s"`, cf.Operator))
+	}
+	rule.WriteString(`>`)
+
+	color := func(c string) string {
+		argb, _ := normalizeColor(c)
+		return fmt.Sprintf(`<color rgb="%s"/>`, argb)
+	}
+
+	switch cf.Type {
+	case ConditionalCellIs, ConditionalExpression:
+		formula1, _ := validationFormula(cf.Formula1)
+		rule.WriteString(fmt.Sprintf(`<formula>%s</formula>`, escapeXML(formula1)))
+		if cf.Operator == ConditionalBetween || cf.Operator == ConditionalNotBetween {
+			formula2, _ := validationFormula(cf.Formula2)
+			rule.WriteString(fmt.Sprintf(`<formula>%s</formula>`, escapeXML(formula2)))
+		}
+	case ConditionalColorScale:
+		rule.WriteString(`<colorScale><cfvo type="min"/>`)
+		if cf.MidColor != "" {
+			rule.WriteString(`<cfvo type="percentile" val="50"/>`)
+		}
+		rule.WriteString(`<cfvo type="max"/>`)
+		rule.WriteString(color(cf.MinColor))
+		if cf.MidColor != "" {
+			rule.WriteString(color(cf.MidColor))
+		}
+		rule.WriteString(color(cf.MaxColor))
+		rule.WriteString(`</colorScale>`)
+	case ConditionalDataBar:
+		rule.WriteString(`<dataBar><cfvo type="min"/><cfvo type="max"/>`)
+		rule.WriteString(color(cf.BarColor))
+		rule.WriteString(`</dataBar>`)
+	case ConditionalIconSet:
+		name := cf.iconSet()
+		rule.WriteString(fmt.Sprintf(`<iconSet iconSet="%s"`, name))
+		if cf.ReverseIcons {
+			rule.WriteString(` reverse="1"`)
+		}
+		rule.WriteString(`>`)
+		size := iconSetSizes[name]
+		for i := 0; i < size; i++ {
+			rule.WriteString(fmt.Sprintf(`<cfvo type="percent" val="%d"/>`, i*100/size))
+		}
+		rule.WriteString(`</iconSet>`)
+	}
+
+	rule.WriteString("</cfRule></conditionalFormatting>\n")
+	return rule.String()
+}
+
+// writeConditionalFormats writes the sheet's <conditionalFormatting>
+// elements covering its data rows
+func (sw *sheetWriter) writeConditionalFormats(formats []ConditionalFormat, dxfIDs []int, dataEnd int) error {
+	firstRow := 0
+	if sw.headersDone {
+		firstRow = 1
+	}
+	if dataEnd <= firstRow {
+		return nil
+	}
+
+	for i := range formats {
+		if _, err := io.WriteString(sw.writer, formats[i].generateXML(firstRow, dataEnd-1, i+1, dxfIDs[i])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
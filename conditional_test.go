@@ -0,0 +1,86 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestConditionalFormats(t *testing.T) {
+	tmpFile := "test_conditional.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	red := &Style{Font: &Font{Color: "9C0006"}, Fill: &Fill{Color: "FFC7CE"}}
+
+	config := DefaultConfig()
+	config.SheetOptions.ConditionalFormats = []ConditionalFormat{
+		{Columns: "B", Type: ConditionalCellIs, Operator: ConditionalGreaterThan, Formula1: 1000, Format: red, StopIfTrue: true},
+		{Columns: "A:C", Type: ConditionalExpression, Formula1: "$C2<TODAY()", Format: &Style{Font: &Font{Bold: true}}},
+		{Columns: "B", Type: ConditionalColorScale, MinColor: "F8696B", MidColor: "FFEB84", MaxColor: "63BE7B"},
+		{Columns: "B", Type: ConditionalDataBar, BarColor: "638EC6"},
+		{Columns: "B", Type: ConditionalIconSet, IconSet: "4Arrows", ReverseIcons: true},
+		{Columns: "B", Type: ConditionalCellIs, Operator: ConditionalBetween, Formula1: 1, Formula2: 10, Format: red},
+	}
+
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"Invoice", "Amount", "Due"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	for i := 1; i <= 3; i++ {
+		if err := writer.WriteRow([]interface{}{i, i * 600, 45000 + i}); err != nil {
+			t.Fatalf("Failed to write row %d: %v", i, err)
+		}
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheetXML := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := []string{
+		`<conditionalFormatting sqref="B2:B4"><cfRule type="cellIs" dxfId="0" priority="1" stopIfTrue="1" operator="greaterThan"><formula>1000</formula></cfRule></conditionalFormatting>`,
+		`<conditionalFormatting sqref="A2:C4"><cfRule type="expression" dxfId="1" priority="2"><formula>$C2&lt;TODAY()</formula></cfRule></conditionalFormatting>`,
+		`<cfRule type="colorScale" priority="3"><colorScale><cfvo type="min"/><cfvo type="percentile" val="50"/><cfvo type="max"/><color rgb="FFF8696B"/><color rgb="FFFFEB84"/><color rgb="FF63BE7B"/></colorScale></cfRule>`,
+		`<cfRule type="dataBar" priority="4"><dataBar><cfvo type="min"/><cfvo type="max"/><color rgb="FF638EC6"/></dataBar></cfRule>`,
+		`<cfRule type="iconSet" priority="5"><iconSet iconSet="4Arrows" reverse="1"><cfvo type="percent" val="0"/><cfvo type="percent" val="25"/><cfvo type="percent" val="50"/><cfvo type="percent" val="75"/></iconSet></cfRule>`,
+		`<cfRule type="cellIs" dxfId="2" priority="6" operator="between"><formula>1</formula><formula>10</formula></cfRule>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheetXML, e) {
+			t.Errorf("Expected %s in sheet XML, got %s", e, sheetXML)
+		}
+	}
+
+	stylesXML := readZipEntry(t, tmpFile, "xl/styles.xml")
+	expectedDxfs := `<dxfs count="3"><dxf><font><color rgb="FF9C0006"/></font><fill><patternFill patternType="solid"><bgColor rgb="FFFFC7CE"/></patternFill></fill></dxf><dxf><font><b/></font></dxf>`
+	if !strings.Contains(stylesXML, expectedDxfs) {
+		t.Errorf("Expected %s in styles XML, got %s", expectedDxfs, stylesXML)
+	}
+}
+
+func TestConditionalFormatErrors(t *testing.T) {
+	invalid := []ConditionalFormat{
+		{Columns: "1", Type: ConditionalDataBar, BarColor: "638EC6"},
+		{Columns: "A", Type: "top10"},
+		{Columns: "A", Type: ConditionalCellIs, Operator: "above", Formula1: 1, Format: &Style{}},
+		{Columns: "A", Type: ConditionalCellIs, Operator: ConditionalBetween, Formula1: 1, Format: &Style{}},
+		{Columns: "A", Type: ConditionalCellIs, Operator: ConditionalEqual, Formula1: 1},
+		{Columns: "A", Type: ConditionalExpression, Format: &Style{}},
+		{Columns: "A", Type: ConditionalColorScale, MinColor: "FF0000"},
+		{Columns: "A", Type: ConditionalDataBar},
+		{Columns: "A", Type: ConditionalIconSet, IconSet: "7Stars"},
+	}
+
+	for i, cf := range invalid {
+		if err := cf.validate(); err == nil {
+			t.Errorf("Expected error for conditional format %d: %+v", i, cf)
+		}
+	}
+}
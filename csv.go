@@ -0,0 +1,160 @@
+package kolayxlsxstream
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// CSVOptions controls how ImportCSV reads its input
+type CSVOptions struct {
+	Delimiter        rune // Field delimiter (default: ',')
+	Comment          rune // Lines starting with it are skipped (default: none)
+	LazyQuotes       bool // Allow quotes in unquoted fields
+	TrimLeadingSpace bool // Ignore leading white space in fields
+	Encoding         Encoding
+
+	// NoHeader means the first line is data rather than column names. The
+	// header row written to the sheet then comes from Columns, if named.
+	NoHeader bool
+
+	// Columns declares the name, type or style of the fields by position.
+	// Missing or ColumnAuto entries are inferred.
+	Columns []ImportColumn
+
+	// SampleRows is the number of rows read before writing, to infer column
+	// types (default: 1000)
+	SampleRows int
+
+	// DateLayouts are the time.Parse layouts of date fields, tried in order
+	// (default: ISO 8601 dates and date-times)
+	DateLayouts []string
+}
+
+// ImportCSV writes the rows of a CSV file, inferring a type for every column
+// from the first SampleRows rows: numbers, booleans and dates are written as
+// such instead of text. Integers with leading zeros or more than 15 digits
+// stay text, and empty fields become empty cells.
+//
+// ImportCSV starts the Writer with the header row if it has not been started,
+// and otherwise writes the header as a row. It does not finish the file.
+// Errors in the input are returned as *ImportError.
+func ImportCSV(w *Writer, r io.Reader, opts CSVOptions) error {
+	if opts.SampleRows < 0 {
+		return fmt.Errorf("sample rows must not be negative")
+	}
+	if opts.SampleRows == 0 {
+		opts.SampleRows = defaultSampleRows
+	}
+
+	decoded, err := newDecoder(r, opts.Encoding)
+	if err != nil {
+		return err
+	}
+	cr := csv.NewReader(decoded)
+	if opts.Delimiter != 0 {
+		cr.Comma = opts.Delimiter
+	}
+	cr.Comment = opts.Comment
+	cr.LazyQuotes = opts.LazyQuotes
+	cr.TrimLeadingSpace = opts.TrimLeadingSpace
+	cr.ReuseRecord = true
+
+	var names []string
+	if !opts.NoHeader {
+		header, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return &ImportError{Line: 1, Err: fmt.Errorf("missing header row")}
+		}
+		if err != nil {
+			return csvError(err)
+		}
+		names = append(names, header...)
+	}
+
+	var sample []importRecord
+	for len(sample) < opts.SampleRows {
+		rec, err := readCSVRecord(cr)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		sample = append(sample, rec)
+	}
+
+	if opts.NoHeader {
+		width := len(opts.Columns)
+		if len(sample) > 0 {
+			width = max(width, len(sample[0].values))
+		}
+		names = make([]string, width)
+	}
+
+	columns, err := newImportColumns(w, names, opts.Columns, false, sample, opts.DateLayouts)
+	if err != nil {
+		return err
+	}
+
+	header := !opts.NoHeader
+	for _, c := range columns {
+		header = header || c.name != ""
+	}
+	ir := &importRows{w: w, columns: columns}
+	if err := ir.start(header); err != nil {
+		return err
+	}
+
+	for _, rec := range sample {
+		if err := ir.add(rec); err != nil {
+			return err
+		}
+	}
+	for {
+		rec, err := readCSVRecord(cr)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if err := ir.add(rec); err != nil {
+			return err
+		}
+	}
+	return ir.flush()
+}
+
+// readCSVRecord reads the next record with the line it starts on. Empty
+// fields are null values.
+func readCSVRecord(cr *csv.Reader) (importRecord, error) {
+	fields, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return importRecord{}, err
+		}
+		return importRecord{}, csvError(err)
+	}
+
+	line, _ := cr.FieldPos(0)
+	rec := importRecord{line: line, values: make([]importValue, len(fields))}
+	for i, field := range fields {
+		if field == "" {
+			rec.values[i] = importValue{kind: kindNull}
+		} else {
+			rec.values[i] = importValue{text: field}
+		}
+	}
+	return rec, nil
+}
+
+// csvError converts a CSV parse error into an ImportError
+func csvError(err error) error {
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return &ImportError{Line: pe.Line, Err: pe.Err}
+	}
+	return fmt.Errorf("failed to read CSV: %w", err)
+}
@@ -0,0 +1,216 @@
+package kolayxlsxstream
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestImportCSV(t *testing.T) {
+	tmpFile := "test_import_csv.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := NewWriter(sink)
+
+	input := "ID;Name;Zip;Score;Active;Joined;Card;Note\n" +
+		"1;Tom & Jerry;01234;85.5;true;2024-01-15;4111111111111111;\n" +
+		"2;\"Semi;colon\";34000;92;FALSE;2024-02-29;4012888888881881;x\n" +
+		"-3;Ayşe;06100;1e3;tRUE;;;12\n" +
+		"4;Max;99999;0;False;9999-12-31;;\n"
+	if err := ImportCSV(writer, strings.NewReader(input), CSVOptions{Delimiter: ';'}); err != nil {
+		t.Fatalf("Failed to import CSV: %v", err)
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 4 {
+		t.Errorf("Expected 4 rows, got %d", stats.TotalRows)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>ID</t></is></c>`,
+		// Integers, text with leading zeros, floats, booleans, dates and
+		// integers too long for Excel
+		`<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="inlineStr"><is><t>Tom &amp; Jerry</t></is></c>` +
+			`<c r="C2" t="inlineStr"><is><t>01234</t></is></c><c r="D2"><v>85.5</v></c><c r="E2" t="b"><v>1</v></c>` +
+			`<c r="F2" s="2"><v>45306</v></c><c r="G2" t="inlineStr"><is><t>4111111111111111</t></is></c><c r="H2"/></row>`,
+		`<c r="B3" t="inlineStr"><is><t>Semi;colon</t></is></c><c r="C3" t="inlineStr"><is><t>34000</t></is></c><c r="D3"><v>92</v></c><c r="E3" t="b"><v>0</v></c>`,
+		`<c r="F4" s="2"/>`,
+		`<c r="D4"><v>1000</v></c>`,
+		// Booleans in any case
+		`<c r="E4" t="b"><v>1</v></c>`,
+		`<c r="E5" t="b"><v>0</v></c>`,
+		`<c r="H4" t="inlineStr"><is><t>12</t></is></c>`,
+		// The last day Excel supports
+		`<c r="F5" s="2"><v>2958465</v></c>`,
+	} {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+
+	if styles := readZipEntry(t, tmpFile, "xl/styles.xml"); !strings.Contains(styles, `formatCode="yyyy-mm-dd"`) {
+		t.Errorf("Expected a date format, got %s", styles)
+	}
+}
+
+func TestImportCSVDeclaredColumns(t *testing.T) {
+	tmpFile := "test_import_csv_declared.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := NewWriter(sink)
+	if err := writer.StartFile([]interface{}{"Report"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	money, err := writer.AddStyle(Style{NumFmt: "#,##0.00"})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+
+	// Without a header line; the declared names become the header row
+	input := "007\t15/01/2024 10:30\t1,5\n" +
+		"42\t29/02/2024 08:00\t2\n"
+	err = ImportCSV(writer, strings.NewReader(input), CSVOptions{
+		Delimiter: '\t',
+		NoHeader:  true,
+		Columns: []ImportColumn{
+			{Name: "Code", Type: ColumnInt},
+			{Name: "When", Type: ColumnDate},
+			{Name: "Amount", Type: ColumnString, StyleID: money},
+		},
+		DateLayouts: []string{"02/01/2006 15:04"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import CSV: %v", err)
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>Code</t></is></c>`,
+		`<row r="3"><c r="A3"><v>7</v></c><c r="B3" s="3"><v>45306.4375</v></c><c r="C3" s="2" t="inlineStr"><is><t>1,5</t></is></c></row>`,
+	} {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+	if styles := readZipEntry(t, tmpFile, "xl/styles.xml"); !strings.Contains(styles, `formatCode="yyyy-mm-dd hh:mm:ss"`) {
+		t.Errorf("Expected a date-time format, got %s", styles)
+	}
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   CSVOptions
+		line   int
+		column string
+		errMsg string
+	}{
+		{
+			name:   "declared type",
+			input:  "A,B\n1,2\n3,x\n",
+			opts:   CSVOptions{Columns: []ImportColumn{{}, {Type: ColumnInt}}},
+			line:   3,
+			column: "B",
+			errMsg: `line 3, column B: cannot parse "x" as int`,
+		},
+		{
+			name:   "field count",
+			input:  "A,B\n1,2\n\n3\n",
+			line:   4,
+			errMsg: "line 4: wrong number of fields",
+		},
+		{
+			name:   "quotes",
+			input:  "A\n\"multi\nline\"\n\"bad\"quote\n",
+			line:   4,
+			errMsg: `line 4: extraneous or missing " in quoted-field`,
+		},
+		{
+			name:   "missing header",
+			input:  "",
+			line:   1,
+			errMsg: "line 1: missing header row",
+		},
+		{
+			name:   "after sample",
+			input:  "A\n1\n2\n3\nthree\n",
+			opts:   CSVOptions{SampleRows: 2, Columns: []ImportColumn{{Name: "Count", Type: ColumnFloat}}},
+			line:   5,
+			column: "Count",
+			errMsg: `line 5, column Count: cannot parse "three" as float`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := NewWriter(discardSink{})
+			err := ImportCSV(writer, strings.NewReader(tt.input), tt.opts)
+
+			var importErr *ImportError
+			if !errors.As(err, &importErr) {
+				t.Fatalf("Expected an ImportError, got %v", err)
+			}
+			if importErr.Line != tt.line || importErr.Column != tt.column {
+				t.Errorf("Expected line %d column %q, got line %d column %q", tt.line, tt.column, importErr.Line, importErr.Column)
+			}
+			if err.Error() != tt.errMsg {
+				t.Errorf("Expected error %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+
+	writer := NewWriter(discardSink{})
+	if err := ImportCSV(writer, strings.NewReader("A\n1\n"), CSVOptions{Columns: []ImportColumn{{StyleID: 9}}}); err == nil || !strings.Contains(err.Error(), "unknown style ID 9") {
+		t.Errorf("Expected unknown style error, got %v", err)
+	}
+}
+
+func TestImportCSVInferredMismatch(t *testing.T) {
+	tmpFile := "test_import_csv_mismatch.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := NewWriter(sink)
+
+	// The column is inferred as numbers from the first two rows; later
+	// values that are not are written as text
+	input := "Count\n1\n2\nN/A\n007\n4\n"
+	if err := ImportCSV(writer, strings.NewReader(input), CSVOptions{SampleRows: 2}); err != nil {
+		t.Fatalf("Failed to import CSV: %v", err)
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<c r="A3"><v>2</v></c>`,
+		`<c r="A4" t="inlineStr"><is><t>N/A</t></is></c>`,
+		`<c r="A5" t="inlineStr"><is><t>007</t></is></c>`,
+		`<c r="A6"><v>4</v></c>`,
+	} {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+}
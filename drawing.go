@@ -0,0 +1,130 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+const (
+	relTypeDrawing = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/drawing"
+
+	contentTypeDrawing = "application/vnd.openxmlformats-officedocument.drawing+xml"
+
+	drawingXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<xdr:wsDr xmlns:xdr="http://schemas.openxmlformats.org/drawingml/2006/spreadsheetDrawing" xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+`
+	drawingXMLFooter = `</xdr:wsDr>`
+
+	drawingRelsXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+`
+
+	// emuPerPixel converts pixels to English Metric Units at 96 DPI
+	emuPerPixel = 9525
+
+	// Default column width and row height in pixels, used for two-cell anchors
+	defaultColumnWidthPx = 64
+	defaultRowHeightPx   = 20
+)
+
+// drawing collects the anchors and relationships of a sheet's drawing part
+type drawing struct {
+	number   int
+	anchors  strings.Builder
+	rels     strings.Builder
+	relCount int
+	shapes   int
+	charts   []*sheetChart // Charts resolved and added when the file is finished
+}
+
+// addRelationship records a relationship for the drawing's .rels part and
+// returns its ID
+func (d *drawing) addRelationship(relType, target string) string {
+	d.relCount++
+	id := fmt.Sprintf("rId%d", d.relCount)
+	d.rels.WriteString(fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s"/>
+`, id, relType, target))
+	return id
+}
+
+// parts returns the drawing part and its relationships part
+func (d *drawing) parts() []sheetPart {
+	return []sheetPart{
+		xmlPart(fmt.Sprintf("xl/drawings/drawing%d.xml", d.number), contentTypeDrawing,
+			drawingXMLHeader+d.anchors.String()+drawingXMLFooter),
+		xmlPart(fmt.Sprintf("xl/drawings/_rels/drawing%d.xml.rels", d.number), "",
+			drawingRelsXMLHeader+d.rels.String()+sheetRelsXMLFooter),
+	}
+}
+
+// writeDrawing writes the sheet's <drawing> element and returns the media,
+// drawing and drawing relationship parts it references. Drawings holding
+// charts are kept until FinishFile, when the charts' ranges are known.
+func (w *Writer) writeDrawing(sw *sheetWriter) ([]sheetPart, error) {
+	w.drawingCount++
+	d := &drawing{number: w.drawingCount, charts: sw.charts}
+
+	relID, err := sw.addRelationship(relTypeDrawing, fmt.Sprintf("../drawings/drawing%d.xml", d.number), false)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := fmt.Fprintf(sw.writer, `<drawing r:id="%s"/>
+`, relID); err != nil {
+		return nil, err
+	}
+
+	var parts []sheetPart
+	for _, img := range sw.images {
+		w.imageCount++
+		ext, contentType := imageContentTypes[img.format][0], imageContentTypes[img.format][1]
+		mediaPart := fmt.Sprintf("xl/media/image%d.%s", w.imageCount, ext)
+		w.addContentDefault(ext, contentType)
+
+		d.shapes++
+		imageRelID := d.addRelationship(relTypeImage, "../media/"+path.Base(mediaPart))
+		d.anchors.WriteString(img.generateAnchorXML(d.shapes, imageRelID))
+
+		data, offset, size := sw.imageData, img.offset, img.size
+		parts = append(parts, sheetPart{
+			name: mediaPart,
+			write: func(pw io.Writer) error {
+				_, err := data.WriteSectionTo(pw, offset, size)
+				return err
+			},
+		})
+	}
+
+	if len(d.charts) > 0 {
+		w.pendingDrawings = append(w.pendingDrawings, d)
+		return parts, nil
+	}
+	return append(parts, d.parts()...), nil
+}
+
+// anchorStart opens a drawing anchor at a cell, with offsets and size in pixels
+func anchorStart(anchor ImageAnchor, row, col, offsetX, offsetY, width, height int) string {
+	from := fmt.Sprintf(`<xdr:from><xdr:col>%d</xdr:col><xdr:colOff>%d</xdr:colOff><xdr:row>%d</xdr:row><xdr:rowOff>%d</xdr:rowOff></xdr:from>`,
+		col, offsetX*emuPerPixel, row, offsetY*emuPerPixel)
+
+	if anchor == AnchorTwoCell {
+		right := offsetX + width
+		bottom := offsetY + height
+		return `<xdr:twoCellAnchor editAs="oneCell">` + from +
+			fmt.Sprintf(`<xdr:to><xdr:col>%d</xdr:col><xdr:colOff>%d</xdr:colOff><xdr:row>%d</xdr:row><xdr:rowOff>%d</xdr:rowOff></xdr:to>`,
+				col+right/defaultColumnWidthPx, (right%defaultColumnWidthPx)*emuPerPixel,
+				row+bottom/defaultRowHeightPx, (bottom%defaultRowHeightPx)*emuPerPixel)
+	}
+
+	return `<xdr:oneCellAnchor>` + from +
+		fmt.Sprintf(`<xdr:ext cx="%d" cy="%d"/>`, width*emuPerPixel, height*emuPerPixel)
+}
+
+// anchorEnd closes a drawing anchor opened by anchorStart
+func anchorEnd(anchor ImageAnchor) string {
+	if anchor == AnchorTwoCell {
+		return "<xdr:clientData/></xdr:twoCellAnchor>\n"
+	}
+	return "<xdr:clientData/></xdr:oneCellAnchor>\n"
+}
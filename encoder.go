@@ -0,0 +1,150 @@
+package kolayxlsxstream
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// cellRef is a cell reference kept in parts, so it can be appended to the
+// row XML without building a string for every cell
+type cellRef struct {
+	col string // Column letters, e.g. "AB"
+	row int    // 1-based row number
+}
+
+// String returns the reference in A1 notation, for error messages
+func (r cellRef) String() string {
+	return r.col + strconv.Itoa(r.row)
+}
+
+// appendTo appends the reference in A1 notation
+func (r cellRef) appendTo(dst []byte) []byte {
+	dst = append(dst, r.col...)
+	return strconv.AppendInt(dst, int64(r.row), 10)
+}
+
+// growColumns precomputes the sheet's column letters for the first n
+// columns. Rows are encoded from the cached letters only, so this must be
+// called before encoding rows concurrently.
+func (sw *sheetWriter) growColumns(n int) {
+	for len(sw.columns) < min(n, maxColumns) {
+		sw.columns = append(sw.columns, columnName(len(sw.columns)))
+	}
+}
+
+// cellRef returns the reference of a cell by zero-based row and column
+func (sw *sheetWriter) cellRef(rowIndex, col int) cellRef {
+	if col < len(sw.columns) {
+		return cellRef{col: sw.columns[col], row: rowIndex + 1}
+	}
+	return cellRef{col: columnName(col), row: rowIndex + 1}
+}
+
+// XML escapes matching encoding/xml's EscapeText
+var (
+	escQuot = []byte("&#34;")
+	escApos = []byte("&#39;")
+	escAmp  = []byte("&amp;")
+	escLT   = []byte("&lt;")
+	escGT   = []byte("&gt;")
+	escTab  = []byte("&#x9;")
+	escNL   = []byte("&#xA;")
+	escCR   = []byte("&#xD;")
+	escFFFD = []byte("\uFFFD")
+)
+
+// needsEscape reports whether an ASCII byte must be escaped in XML text
+var needsEscape = func() (table [utf8.RuneSelf]bool) {
+	for b := range table {
+		table[b] = b < 0x20
+	}
+	for _, b := range `"'&<>` {
+		table[b] = true
+	}
+	return table
+}()
+
+// appendEscaped appends s escaped for XML text or attribute values, with the
+// same output as xml.EscapeText. Strings of plain ASCII text are appended as
+// they are.
+func appendEscaped(dst []byte, s string) []byte {
+	i := 0
+	for i < len(s) && s[i] < utf8.RuneSelf && !needsEscape[s[i]] {
+		i++
+	}
+	if i == len(s) {
+		return append(dst, s...)
+	}
+
+	dst = append(dst, s[:i]...)
+	last := i
+	for i < len(s) {
+		r, width := utf8.DecodeRuneInString(s[i:])
+		i += width
+
+		var esc []byte
+		switch r {
+		case '"':
+			esc = escQuot
+		case '\'':
+			esc = escApos
+		case '&':
+			esc = escAmp
+		case '<':
+			esc = escLT
+		case '>':
+			esc = escGT
+		case '\t':
+			esc = escTab
+		case '\n':
+			esc = escNL
+		case '\r':
+			esc = escCR
+		default:
+			if !isInCharacterRange(r) || (r == utf8.RuneError && width == 1) {
+				esc = escFFFD
+				break
+			}
+			continue
+		}
+
+		dst = append(dst, s[last:i-width]...)
+		dst = append(dst, esc...)
+		last = i
+	}
+	return append(dst, s[last:]...)
+}
+
+// isInCharacterRange reports whether r may appear in an XML document
+func isInCharacterRange(r rune) bool {
+	return r == 0x09 || r == 0x0A || r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
+
+// appendText appends a <t> element, preserving leading and trailing
+// whitespace that Excel would otherwise trim
+func appendText(dst []byte, s string) []byte {
+	if s != strings.TrimSpace(s) {
+		dst = append(dst, `<t xml:space="preserve">`...)
+	} else {
+		dst = append(dst, `<t>`...)
+	}
+	dst = appendEscaped(dst, s)
+	return append(dst, `</t>`...)
+}
+
+// appendFloat appends the shortest representation of v that round-trips,
+// as described for formatFloat
+func appendFloat(dst []byte, v float64, bitSize int) []byte {
+	if v == 0 {
+		return append(dst, '0')
+	}
+	if abs := math.Abs(v); abs >= 1e-6 && abs < 1e21 {
+		return strconv.AppendFloat(dst, v, 'f', -1, bitSize)
+	}
+	return strconv.AppendFloat(dst, v, 'E', -1, bitSize)
+}
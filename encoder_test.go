@@ -0,0 +1,120 @@
+package kolayxlsxstream
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func TestAppendEscapedMatchesEncodingXML(t *testing.T) {
+	values := []string{
+		"",
+		"plain text 123",
+		`Tom & Jerry <"quoted"> 'single'`,
+		"tab\tnew\nline\rreturn",
+		"control \x01\x1f chars",
+		"Türkçe ğüşıöç 日本語 🎉",
+		"invalid \xff\xfe utf-8",
+		"replacement \uFFFD kept",
+		"\uFFFE noncharacter",
+	}
+
+	for _, v := range values {
+		var expected strings.Builder
+		xml.EscapeText(&expected, []byte(v))
+		if got := string(appendEscaped(nil, v)); got != expected.String() {
+			t.Errorf("appendEscaped(%q) = %q, expected %q", v, got, expected.String())
+		}
+	}
+}
+
+func TestColumnNames(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 701: "ZZ", 702: "AAA", 16383: "XFD"}
+	for col, expected := range tests {
+		if got := columnName(col); got != expected {
+			t.Errorf("columnName(%d) = %q, expected %q", col, got, expected)
+		}
+	}
+
+	sw := newTestSheetWriter(nil)
+	sw.growColumns(30)
+	if len(sw.columns) != 30 || sw.columns[29] != "AD" {
+		t.Errorf("Expected 30 cached columns ending in AD, got %v", sw.columns)
+	}
+	if ref := sw.cellRef(9, 40); ref.String() != "AO10" {
+		t.Errorf("Expected AO10 beyond the cached columns, got %s", ref)
+	}
+}
+
+func TestWriteRowReusesBuffer(t *testing.T) {
+	sw := newTestSheetWriter(nil)
+	var out strings.Builder
+	sw.writer = &out
+
+	row := []interface{}{1, "a & b", 2.5, true}
+	for i := 0; i < 3; i++ {
+		if err := sw.writeRow(row, nil); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+
+	expected := `<row r="3"><c r="A3"><v>1</v></c><c r="B3" t="inlineStr"><is><t>a &amp; b</t></is></c><c r="C3"><v>2.5</v></c><c r="D3" t="b"><v>1</v></c></row>` + "\n"
+	if !strings.HasSuffix(out.String(), expected) {
+		t.Errorf("Expected %s at the end, got %s", expected, out.String())
+	}
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := sw.writeRow(row, nil); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	})
+	if allocs > 0 {
+		t.Errorf("Expected no allocations per row, got %v", allocs)
+	}
+}
+
+// benchmarkEncodeRow appends the same row to a reused buffer
+func benchmarkEncodeRow(b *testing.B, row []interface{}) {
+	sw := newTestSheetWriter(nil)
+	sw.growColumns(len(row))
+	var buf []byte
+	b.ReportAllocs()
+
+	i := 0
+	for b.Loop() {
+		var err error
+		if buf, err = sw.appendRow(buf[:0], i, row, nil); err != nil {
+			b.Fatalf("Failed to encode row: %v", err)
+		}
+		i = (i + 1) % 1048576
+	}
+	b.SetBytes(int64(len(buf)))
+}
+
+func BenchmarkEncodeRowMixed(b *testing.B) {
+	benchmarkEncodeRow(b, []interface{}{
+		123456, "Customer Name", 1234.5678, true, int64(-42), "user@example.com", float32(0.25), nil,
+	})
+}
+
+func BenchmarkEncodeRowStrings(b *testing.B) {
+	benchmarkEncodeRow(b, []interface{}{
+		"plain", "Tom & Jerry", "  indented", "Türkçe karakterler", "<tag>", "line\nbreak",
+	})
+}
+
+func BenchmarkEncodeRowWide(b *testing.B) {
+	row := make([]interface{}, 100)
+	for i := range row {
+		row[i] = float64(i) * 1.5
+	}
+	benchmarkEncodeRow(b, row)
+}
+
+func BenchmarkEncodeRowStyled(b *testing.B) {
+	row := make([]interface{}, 10)
+	for i := range row {
+		row[i] = Cell{Value: i, StyleID: 0, Type: CellTypeNumber}
+	}
+	benchmarkEncodeRow(b, row)
+}
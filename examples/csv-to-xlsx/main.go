@@ -1,22 +1,24 @@
 package main
 
 import (
-	"encoding/csv"
+	"errors"
 	"flag"
 	"fmt"
-	"io"
 	"log"
 	"os"
 
 	"github.com/turgutahmet/kolayxlsxstream"
 )
 
-// This example converts a CSV file to XLSX format
+// This example converts a CSV file to XLSX format, writing numbers, booleans
+// and dates as typed cells
 
 func main() {
 	csvPath := flag.String("input", "input.csv", "Input CSV file path")
 	xlsxPath := flag.String("output", "output.xlsx", "Output XLSX file path")
 	hasHeaders := flag.Bool("headers", true, "First row contains headers")
+	delimiter := flag.String("delimiter", ",", "Field delimiter")
+	latin1 := flag.Bool("latin1", false, "Input is Latin-1 (ISO 8859-1) instead of UTF-8/UTF-16")
 	flag.Parse()
 
 	fmt.Printf("Converting CSV to XLSX...\n")
@@ -30,11 +32,6 @@ func main() {
 	}
 	defer csvFile.Close()
 
-	// Create CSV reader
-	csvReader := csv.NewReader(csvFile)
-	csvReader.LazyQuotes = true
-	csvReader.TrimLeadingSpace = true
-
 	// Create XLSX writer
 	sink, err := kolayxlsxstream.NewFileSink(*xlsxPath)
 	if err != nil {
@@ -43,50 +40,27 @@ func main() {
 
 	writer := kolayxlsxstream.NewWriter(sink)
 
-	// Read and convert
-	rowCount := 0
-	firstRow := true
-
-	for {
-		record, err := csvReader.Read()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			log.Fatalf("Error reading CSV at row %d: %v", rowCount+1, err)
-		}
-
-		// Convert []string to []interface{}
-		row := make([]interface{}, len(record))
-		for i, v := range record {
-			row[i] = v
-		}
-
-		// Handle first row
-		if firstRow {
-			firstRow = false
-			if *hasHeaders {
-				if err := writer.StartFile(row); err != nil {
-					log.Fatal(err)
-				}
-				rowCount++
-				continue
-			} else {
-				if err := writer.StartFile(); err != nil {
-					log.Fatal(err)
-				}
-			}
-		}
-
-		// Write data row
-		if err := writer.WriteRow(row); err != nil {
-			log.Fatalf("Failed to write row %d: %v", rowCount+1, err)
-		}
+	// Column types are inferred from the first 1000 rows
+	opts := kolayxlsxstream.CSVOptions{
+		LazyQuotes:       true,
+		TrimLeadingSpace: true,
+		NoHeader:         !*hasHeaders,
+	}
+	if d := []rune(*delimiter); len(d) == 1 {
+		opts.Delimiter = d[0]
+	} else {
+		log.Fatalf("Delimiter must be a single character, got %q", *delimiter)
+	}
+	if *latin1 {
+		opts.Encoding = kolayxlsxstream.EncodingLatin1
+	}
 
-		rowCount++
-		if rowCount%10000 == 0 {
-			fmt.Printf("  Progress: %d rows processed\n", rowCount)
+	if err := kolayxlsxstream.ImportCSV(writer, csvFile, opts); err != nil {
+		var importErr *kolayxlsxstream.ImportError
+		if errors.As(err, &importErr) {
+			log.Fatalf("Invalid CSV at line %d: %v", importErr.Line, importErr.Err)
 		}
+		log.Fatalf("Failed to convert CSV: %v", err)
 	}
 
 	// Finish
@@ -100,11 +74,6 @@ func main() {
 	xlsxInfo, _ := os.Stat(*xlsxPath)
 
 	fmt.Printf("\n✅ Conversion completed!\n")
-	fmt.Printf("Total rows:     %d", rowCount)
-	if *hasHeaders {
-		fmt.Printf(" (including header)")
-	}
-	fmt.Printf("\n")
 	fmt.Printf("Data rows:      %d\n", stats.TotalRows)
 	fmt.Printf("Total sheets:   %d\n", stats.TotalSheets)
 	fmt.Printf("Duration:       %.2f seconds\n", stats.Duration)
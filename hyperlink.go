@@ -0,0 +1,83 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Hyperlink is a cell value that links to a URL or, when URL starts with
+// "#", to a location inside the workbook (e.g. "#Orders!A1")
+type Hyperlink struct {
+	URL     string // Link target
+	Display string // Cell text (default: URL)
+	Tooltip string // Text shown when hovering over the link
+}
+
+const (
+	relTypeHyperlink = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink"
+
+	// hyperlinkStyleID is the cellXfs index of the built-in hyperlink style
+	hyperlinkStyleID = 1
+)
+
+// pendingHyperlink is a link of the row being generated. Links are recorded
+// on the sheet only after the whole row is written, so a row that fails
+// leaves no dangling links or relationships.
+type pendingHyperlink struct {
+	ref     cellRef
+	link    Hyperlink
+	display string
+}
+
+// appendHyperlinkCell appends the XML for a hyperlink cell and keeps the
+// link until the row is written. Unstyled links use the built-in hyperlink
+// style.
+func (sw *sheetWriter) appendHyperlinkCell(dst []byte, ref cellRef, style int, h Hyperlink) ([]byte, error) {
+	if h.URL == "" {
+		return dst, fmt.Errorf("cell %s: hyperlink URL is empty", ref)
+	}
+
+	display := h.Display
+	if display == "" {
+		display = strings.TrimPrefix(h.URL, "#")
+	}
+	sw.rowLinks = append(sw.rowLinks, pendingHyperlink{ref: ref, link: h, display: display})
+
+	if style == 0 {
+		style = hyperlinkStyleID
+	}
+	return appendStringCell(dst, ref, style, display), nil
+}
+
+// recordHyperlinks records the links of the row just written, so they are
+// written in the sheet's <hyperlinks> element
+func (sw *sheetWriter) recordHyperlinks() error {
+	links := sw.rowLinks
+	sw.rowLinks = sw.rowLinks[:0]
+
+	for _, p := range links {
+		var link strings.Builder
+		link.WriteString(fmt.Sprintf(`<hyperlink ref="%s"`, p.ref))
+		if location, internal := strings.CutPrefix(p.link.URL, "#"); internal {
+			link.WriteString(fmt.Sprintf(` location="%s"`, escapeXML(location)))
+		} else {
+			id, err := sw.addRelationship(relTypeHyperlink, p.link.URL, true)
+			if err != nil {
+				return err
+			}
+			link.WriteString(fmt.Sprintf(` r:id="%s"`, id))
+		}
+		if p.link.Tooltip != "" {
+			link.WriteString(fmt.Sprintf(` tooltip="%s"`, escapeXML(p.link.Tooltip)))
+		}
+		link.WriteString(fmt.Sprintf(` display="%s"/>`, escapeXML(p.display)))
+
+		if sw.hyperlinks == nil {
+			sw.hyperlinks = newSpillBuffer(sw.config.SpillThreshold, sw.config.TempDir)
+		}
+		if _, err := sw.hyperlinks.WriteString(link.String()); err != nil {
+			return fmt.Errorf("failed to record hyperlink: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,135 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHyperlinks(t *testing.T) {
+	tmpFile := "test_hyperlinks.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink)
+
+	if err := writer.StartFile([]interface{}{"Customer", "Link"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	rows := [][]interface{}{
+		{"Acme", Hyperlink{URL: "https://example.com/customers?id=1&tab=orders", Display: "Open", Tooltip: "Customer 1"}},
+		{"Globex", Hyperlink{URL: "#Sheet1!A1"}},
+	}
+	if err := writer.WriteRows(rows); err != nil {
+		t.Fatalf("Failed to write rows: %v", err)
+	}
+
+	if err := writer.WriteRow([]interface{}{Hyperlink{}}); err == nil {
+		t.Error("Expected error for hyperlink without URL")
+	}
+	// A row failing after a link cell leaves no link behind
+	if err := writer.WriteRow([]interface{}{Hyperlink{URL: "https://example.com/failed"}, ErrorValue("#BOGUS!")}); err == nil {
+		t.Error("Expected error for unknown error value")
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheetXML := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := []string{
+		`<c r="B2" s="1" t="inlineStr"><is><t>Open</t></is></c>`,
+		`<c r="B3" s="1" t="inlineStr"><is><t>Sheet1!A1</t></is></c>`,
+		`</sheetData>
+<hyperlinks><hyperlink ref="B2" r:id="rId1" tooltip="Customer 1" display="Open"/><hyperlink ref="B3" location="Sheet1!A1" display="Sheet1!A1"/></hyperlinks>
+</worksheet>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheetXML, e) {
+			t.Errorf("Expected %s in sheet XML, got %s", e, sheetXML)
+		}
+	}
+
+	relsXML := readZipEntry(t, tmpFile, "xl/worksheets/_rels/sheet1.xml.rels")
+	expectedRel := `<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/hyperlink" Target="https://example.com/customers?id=1&amp;tab=orders" TargetMode="External"/>`
+	if !strings.Contains(relsXML, expectedRel) {
+		t.Errorf("Expected %s in rels XML, got %s", expectedRel, relsXML)
+	}
+	if strings.Count(relsXML, "<Relationship ") != 1 {
+		t.Errorf("Expected a single relationship, got %s", relsXML)
+	}
+}
+
+func TestHyperlinksSpillToDisk(t *testing.T) {
+	tmpFile := "test_hyperlinks_spill.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	tempDir := t.TempDir()
+	config := DefaultConfig()
+	config.SpillThreshold = 256
+	config.TempDir = tempDir
+	config.MaxRowsPerSheet = 300
+
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	for i := 1; i <= 500; i++ {
+		link := Hyperlink{URL: fmt.Sprintf("https://example.com/%d", i)}
+		if err := writer.WriteRow([]interface{}{i, link}); err != nil {
+			t.Fatalf("Failed to write row %d: %v", i, err)
+		}
+	}
+
+	// The first sheet's links must have spilled and been cleaned up on close
+	if entries, _ := os.ReadDir(tempDir); len(entries) == 0 {
+		t.Error("Expected the open sheet's hyperlinks to be spilled to disk")
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected spill files to be removed, found %d", len(entries))
+	}
+
+	for sheet, count := range map[int]int{1: 300, 2: 200} {
+		sheetXML := readZipEntry(t, tmpFile, fmt.Sprintf("xl/worksheets/sheet%d.xml", sheet))
+		if got := strings.Count(sheetXML, "<hyperlink "); got != count {
+			t.Errorf("Expected %d hyperlinks in sheet %d, got %d", count, sheet, got)
+		}
+
+		relsXML := readZipEntry(t, tmpFile, fmt.Sprintf("xl/worksheets/_rels/sheet%d.xml.rels", sheet))
+		if got := strings.Count(relsXML, "<Relationship "); got != count {
+			t.Errorf("Expected %d relationships in sheet %d, got %d", count, sheet, got)
+		}
+	}
+}
+
+func TestSpillBufferDefaultThreshold(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// A Config literal without SpillThreshold keeps small collections in memory
+	sb := newSpillBuffer(0, tempDir)
+	defer sb.Close()
+	if _, err := sb.WriteString(strings.Repeat("x", 4096)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected no spill files below the default threshold, found %d", len(entries))
+	}
+}
@@ -0,0 +1,153 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"image"
+	"io"
+	"strings"
+
+	// Register the decoders used to detect image format and dimensions
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+)
+
+// ImageAnchor sets how an image moves and resizes with the cells beneath it
+type ImageAnchor int
+
+const (
+	// AnchorOneCell moves the image with its top-left cell but keeps its size
+	AnchorOneCell ImageAnchor = iota
+	// AnchorTwoCell attaches both corners of the image to cells, assuming the
+	// default column width and row height
+	AnchorTwoCell
+)
+
+// ImageOptions configures an image added with AddImage
+type ImageOptions struct {
+	// Width and Height set the displayed size in pixels. When only one is set
+	// the other keeps the aspect ratio; when neither is set the image's own
+	// dimensions are used.
+	Width  int
+	Height int
+
+	// OffsetX and OffsetY move the image from the anchor cell's top-left corner, in pixels
+	OffsetX int
+	OffsetY int
+
+	Anchor  ImageAnchor
+	AltText string
+}
+
+const relTypeImage = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/image"
+
+// imageContentTypes maps detected image formats to file extensions and content types
+var imageContentTypes = map[string][2]string{
+	"png":  {"png", "image/png"},
+	"jpeg": {"jpeg", "image/jpeg"},
+	"gif":  {"gif", "image/gif"},
+}
+
+// sheetImage is an image waiting to be written with its sheet's drawing
+type sheetImage struct {
+	offset, size int64 // Position of the image data in the sheet's image buffer
+	format       string
+
+	row, col         int
+	offsetX, offsetY int
+	width, height    int
+	anchor           ImageAnchor
+	altText          string
+}
+
+// AddImage embeds a PNG, JPEG or GIF image anchored to a cell of the current
+// sheet, e.g. "D2". The image is buffered with the sheet's other images
+// (spilling to disk above Config.SpillThreshold) and written to the file when
+// the sheet is closed.
+func (w *Writer) AddImage(cell string, r io.Reader, opts *ImageOptions) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+	return w.current.addImage(cell, r, opts, w.sheetCapacity())
+}
+
+// addImage buffers an image and records its placement on a sheet holding up
+// to capacity rows
+func (sw *sheetWriter) addImage(cell string, r io.Reader, opts *ImageOptions, capacity int) error {
+	if opts == nil {
+		opts = &ImageOptions{}
+	}
+
+	row, col, err := parseCellReference(cell)
+	if err != nil {
+		return err
+	}
+	if row >= capacity {
+		return fmt.Errorf("image cell %s exceeds the sheet limit of %d rows", cell, capacity)
+	}
+	if opts.Width < 0 || opts.Height < 0 || opts.OffsetX < 0 || opts.OffsetY < 0 {
+		return fmt.Errorf("image size and offsets must not be negative")
+	}
+	if opts.Anchor != AnchorOneCell && opts.Anchor != AnchorTwoCell {
+		return fmt.Errorf("unknown image anchor %d", opts.Anchor)
+	}
+
+	if sw.imageData == nil {
+		sw.imageData = newSpillBuffer(sw.config.SpillThreshold, sw.config.TempDir)
+	}
+	data := sw.imageData
+	offset := data.Len()
+
+	// Detect the format and dimensions while copying the header to the
+	// buffer. The data of an image that fails is left unreferenced.
+	imgConfig, format, err := image.DecodeConfig(io.TeeReader(r, data))
+	if err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+	if _, ok := imageContentTypes[format]; !ok {
+		return fmt.Errorf("unsupported image format %q", format)
+	}
+	if _, err := io.Copy(data, r); err != nil {
+		return fmt.Errorf("failed to read image: %w", err)
+	}
+
+	width, height := opts.Width, opts.Height
+	switch {
+	case width == 0 && height == 0:
+		width, height = imgConfig.Width, imgConfig.Height
+	case width == 0:
+		width = max(1, height*imgConfig.Width/max(imgConfig.Height, 1))
+	case height == 0:
+		height = max(1, width*imgConfig.Height/max(imgConfig.Width, 1))
+	}
+
+	sw.images = append(sw.images, &sheetImage{
+		offset:  offset,
+		size:    data.Len() - offset,
+		format:  format,
+		row:     row,
+		col:     col,
+		offsetX: opts.OffsetX,
+		offsetY: opts.OffsetY,
+		width:   width,
+		height:  height,
+		anchor:  opts.Anchor,
+		altText: opts.AltText,
+	})
+
+	return nil
+}
+
+// generateAnchorXML generates the drawing anchor placing the image
+func (img *sheetImage) generateAnchorXML(id int, relID string) string {
+	cx, cy := img.width*emuPerPixel, img.height*emuPerPixel
+
+	var anchor strings.Builder
+	anchor.WriteString(anchorStart(img.anchor, img.row, img.col, img.offsetX, img.offsetY, img.width, img.height))
+	anchor.WriteString(fmt.Sprintf(`<xdr:pic><xdr:nvPicPr><xdr:cNvPr id="%d" name="Picture %d" descr="%s"/><xdr:cNvPicPr><a:picLocks noChangeAspect="1"/></xdr:cNvPicPr></xdr:nvPicPr>`,
+		id+1, id, escapeXML(img.altText)))
+	anchor.WriteString(fmt.Sprintf(`<xdr:blipFill><a:blip r:embed="%s"/><a:stretch><a:fillRect/></a:stretch></xdr:blipFill>`, relID))
+	anchor.WriteString(fmt.Sprintf(`<xdr:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%d" cy="%d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></xdr:spPr></xdr:pic>`, cx, cy))
+	anchor.WriteString(anchorEnd(img.anchor))
+	return anchor.String()
+}
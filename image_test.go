@@ -0,0 +1,206 @@
+package kolayxlsxstream
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"strings"
+	"testing"
+)
+
+func testImage(t *testing.T, width, height int, encode func(*bytes.Buffer, image.Image) error) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	img.Set(0, 0, color.RGBA{R: 255, A: 255})
+	var buf bytes.Buffer
+	if err := encode(&buf, img); err != nil {
+		t.Fatalf("Failed to encode image: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestImages(t *testing.T) {
+	tmpFile := "test_images.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	config.SpillThreshold = 16 // force image data to disk
+	writer := NewWriter(sink, config)
+
+	pngData := testImage(t, 40, 20, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	jpegData := testImage(t, 100, 50, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) })
+
+	if err := writer.StartFile([]interface{}{"ID", "Photo"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	if err := writer.AddImage("B2", bytes.NewReader(pngData), &ImageOptions{AltText: "Red & white"}); err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+	if err := writer.WriteRow([]interface{}{1, nil}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+	if err := writer.AddImage("C1", bytes.NewReader(jpegData), &ImageOptions{Width: 80, Anchor: AnchorTwoCell, OffsetY: 5}); err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+
+	if err := writer.AddImage("A1", strings.NewReader("not an image"), nil); err == nil {
+		t.Error("Expected error for unrecognised image data")
+	}
+	if err := writer.AddImage("A4", bytes.NewReader(pngData), nil); err == nil {
+		t.Error("Expected error for image beyond MaxRowsPerSheet")
+	}
+
+	// Roll over to a second sheet with its own drawing
+	for i := 2; i <= 3; i++ {
+		if err := writer.WriteRow([]interface{}{i, nil}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+	if err := writer.AddImage("A2", bytes.NewReader(pngData), &ImageOptions{Height: 10}); err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheet1, `<drawing r:id="rId1"/>
+</worksheet>`) {
+		t.Errorf("Expected drawing in sheet 1, got %s", sheet1)
+	}
+
+	if got := readZipEntry(t, tmpFile, "xl/media/image1.png"); got != string(pngData) {
+		t.Error("Expected PNG data to be copied unchanged")
+	}
+	if got := readZipEntry(t, tmpFile, "xl/media/image2.jpeg"); got != string(jpegData) {
+		t.Error("Expected JPEG data to be copied unchanged")
+	}
+
+	drawing := readZipEntry(t, tmpFile, "xl/drawings/drawing1.xml")
+	expected := []string{
+		// 40x20 px at B2
+		`<xdr:oneCellAnchor><xdr:from><xdr:col>1</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>1</xdr:row><xdr:rowOff>0</xdr:rowOff></xdr:from><xdr:ext cx="381000" cy="190500"/>`,
+		`descr="Red &amp; white"`,
+		`<a:blip r:embed="rId1"/>`,
+		// 80x40 px at C1, offset 5px down: ends at column D + 16px, row 3 + 5px
+		`<xdr:twoCellAnchor editAs="oneCell"><xdr:from><xdr:col>2</xdr:col><xdr:colOff>0</xdr:colOff><xdr:row>0</xdr:row><xdr:rowOff>47625</xdr:rowOff></xdr:from><xdr:to><xdr:col>3</xdr:col><xdr:colOff>152400</xdr:colOff><xdr:row>2</xdr:row><xdr:rowOff>47625</xdr:rowOff></xdr:to>`,
+		`<a:ext cx="762000" cy="381000"/>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(drawing, e) {
+			t.Errorf("Expected %s in drawing, got %s", e, drawing)
+		}
+	}
+
+	drawingRels := readZipEntry(t, tmpFile, "xl/drawings/_rels/drawing1.xml.rels")
+	for _, e := range []string{`Target="../media/image1.png"`, `Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="../media/image2.jpeg"`} {
+		if !strings.Contains(drawingRels, e) {
+			t.Errorf("Expected %s in drawing rels, got %s", e, drawingRels)
+		}
+	}
+
+	if !strings.Contains(readZipEntry(t, tmpFile, "xl/worksheets/_rels/sheet1.xml.rels"), `Target="../drawings/drawing1.xml"`) {
+		t.Error("Expected drawing relationship in sheet rels")
+	}
+
+	// 20px high image scaled to 10px keeps its aspect ratio
+	if !strings.Contains(readZipEntry(t, tmpFile, "xl/drawings/drawing2.xml"), `<xdr:ext cx="190500" cy="95250"/>`) {
+		t.Error("Expected scaled image in second drawing")
+	}
+
+	contentTypes := readZipEntry(t, tmpFile, "[Content_Types].xml")
+	for _, e := range []string{
+		`<Default Extension="jpeg" ContentType="image/jpeg"/>`,
+		`<Default Extension="png" ContentType="image/png"/>`,
+		`<Override PartName="/xl/drawings/drawing2.xml" ContentType="application/vnd.openxmlformats-officedocument.drawing+xml"/>`,
+	} {
+		if !strings.Contains(contentTypes, e) {
+			t.Errorf("Expected %s in content types, got %s", e, contentTypes)
+		}
+	}
+}
+
+func TestImagesTotalsRow(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	config.SheetOptions.Table = &Table{TotalsRow: true}
+	writer := NewWriter(discardSink{}, config)
+	if err := writer.StartFile([]interface{}{"ID"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	// The last row of the sheet is kept for the totals row
+	pngData := testImage(t, 4, 4, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	if err := writer.AddImage("A3", bytes.NewReader(pngData), nil); err == nil {
+		t.Error("Expected error for image on the totals row")
+	}
+	if err := writer.AddImage("A2", bytes.NewReader(pngData), nil); err != nil {
+		t.Errorf("Failed to add image: %v", err)
+	}
+}
+
+func TestImagesOnNamedSheet(t *testing.T) {
+	tmpFile := "test_images_named.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	tempDir := t.TempDir()
+	config := DefaultConfig()
+	config.SpillThreshold = 16
+	config.TempDir = tempDir
+	writer := NewWriter(sink, config)
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	sheet, err := writer.Sheet("Photos")
+	if err != nil {
+		t.Fatalf("Failed to add sheet: %v", err)
+	}
+
+	pngData := testImage(t, 40, 20, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	jpegData := testImage(t, 10, 10, func(b *bytes.Buffer, img image.Image) error { return jpeg.Encode(b, img, nil) })
+	if err := sheet.AddImage("A1", bytes.NewReader(pngData), &ImageOptions{Anchor: ImageAnchor(7)}); err == nil || !strings.Contains(err.Error(), "unknown image anchor") {
+		t.Errorf("Expected unknown anchor error, got %v", err)
+	}
+	if err := sheet.AddImage("A1", strings.NewReader("not an image"), nil); err == nil {
+		t.Error("Expected error for unrecognised image data")
+	}
+	before, _ := os.ReadDir(tempDir)
+	for _, data := range [][]byte{pngData, jpegData, pngData} {
+		if err := sheet.AddImage("B2", bytes.NewReader(data), nil); err != nil {
+			t.Fatalf("Failed to add image: %v", err)
+		}
+	}
+
+	// The sheet's images share one spill file
+	if after, _ := os.ReadDir(tempDir); len(after) != len(before)+1 {
+		t.Errorf("Expected the images to share a spill file, found %d new files", len(after)-len(before))
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	if !strings.Contains(readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml"), `<drawing r:id="rId1"/>`) {
+		t.Error("Expected drawing in the named sheet")
+	}
+	for name, data := range map[string][]byte{"xl/media/image1.png": pngData, "xl/media/image2.jpeg": jpegData, "xl/media/image3.png": pngData} {
+		if got := readZipEntry(t, tmpFile, name); got != string(data) {
+			t.Errorf("Expected %s to be copied unchanged", name)
+		}
+	}
+}
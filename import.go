@@ -0,0 +1,611 @@
+package kolayxlsxstream
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+const (
+	// defaultSampleRows is the number of rows used to infer column types
+	defaultSampleRows = 1000
+
+	// importBatchRows is the number of imported rows passed to each WriteRows
+	// call, so large imports can use the pipelined mode
+	importBatchRows = 1024
+
+	// maxImportDigits is the most digits an integer may have to be inferred as
+	// a number; longer ones, such as card or account numbers, lose precision
+	// in Excel and are kept as text
+	maxImportDigits = 15
+)
+
+// defaultDateLayouts are the layouts tried for date columns when none are set
+var defaultDateLayouts = []string{
+	"2006-01-02",
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+}
+
+// ColumnType is the cell type of an imported column
+type ColumnType int
+
+const (
+	// ColumnAuto infers the type from the sampled rows. Values that do not
+	// match the inferred type later in the input are written as text.
+	ColumnAuto ColumnType = iota
+	// ColumnString writes every value as text
+	ColumnString
+	// ColumnInt writes integers as numbers
+	ColumnInt
+	// ColumnFloat writes decimal numbers as numbers
+	ColumnFloat
+	// ColumnBool writes true/false values, in any case, as booleans
+	ColumnBool
+	// ColumnDate parses values with the date layouts and writes them as dates
+	ColumnDate
+)
+
+// String returns the name of the column type
+func (t ColumnType) String() string {
+	switch t {
+	case ColumnString:
+		return "string"
+	case ColumnInt:
+		return "int"
+	case ColumnFloat:
+		return "float"
+	case ColumnBool:
+		return "bool"
+	case ColumnDate:
+		return "date"
+	default:
+		return "auto"
+	}
+}
+
+// ImportColumn declares the name, type and style of an imported column.
+// Values of a declared type that do not parse are reported as errors.
+type ImportColumn struct {
+	Name    string // Header text; the key of the values in JSON Lines input
+	Type    ColumnType
+	StyleID int // Style registered with Writer.AddStyle; dates default to a date format
+}
+
+// Encoding is the character encoding of imported text
+type Encoding int
+
+const (
+	// EncodingAuto reads UTF-8, or UTF-16 when the input starts with a UTF-16
+	// byte order mark
+	EncodingAuto Encoding = iota
+	EncodingUTF8
+	EncodingUTF16LE
+	EncodingUTF16BE
+	// EncodingLatin1 reads ISO 8859-1, one byte per character
+	EncodingLatin1
+)
+
+// ImportError reports input that could not be imported, with the line it
+// starts on
+type ImportError struct {
+	Line   int    // 1-based line number in the input
+	Column string // Column name or letter, empty for errors about a whole line
+	Err    error
+}
+
+// Error returns the error with its position in the input
+func (e *ImportError) Error() string {
+	if e.Column != "" {
+		return fmt.Sprintf("line %d, column %s: %v", e.Line, e.Column, e.Err)
+	}
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying error
+func (e *ImportError) Unwrap() error {
+	return e.Err
+}
+
+// valueKind is what is known about the type of an input value
+type valueKind int
+
+const (
+	kindText   valueKind = iota // CSV field: any type
+	kindNull                    // Empty CSV field or JSON null
+	kindString                  // JSON string: text or a date
+	kindNumber                  // JSON number
+	kindBool                    // JSON boolean
+	kindRaw                     // JSON object or array, written as its JSON text
+)
+
+// importValue is one input value with the text it was read as
+type importValue struct {
+	text string
+	kind valueKind
+}
+
+// importRecord is a row of input values and the line it starts on
+type importRecord struct {
+	line   int
+	values []importValue
+}
+
+// importColumn converts the values of one column into cells
+type importColumn struct {
+	name     string
+	label    string // Name, or the column letter when unnamed, for errors
+	typ      ColumnType
+	declared bool   // Values of a declared type must parse
+	layout   string // Date layout when typ is ColumnDate
+	layouts  []string
+	style    int
+}
+
+// columnGuess tracks the types every sampled value of a column can have
+type columnGuess struct {
+	seen    bool
+	ints    bool
+	floats  bool
+	bools   bool
+	layouts []string // Date layouts that parse every value so far
+}
+
+// newColumnGuess returns a guess allowing every type
+func newColumnGuess(layouts []string) *columnGuess {
+	return &columnGuess{ints: true, floats: true, bools: true, layouts: layouts}
+}
+
+// observe narrows the guess to the types v can have
+func (g *columnGuess) observe(v importValue) {
+	if v.kind == kindNull {
+		return
+	}
+	g.seen = true
+
+	numeric := v.kind == kindText || v.kind == kindNumber
+	g.ints = g.ints && numeric && isPlainInt(v.text)
+	g.floats = g.floats && numeric && isPlainFloat(v.text)
+	_, isBool := parseBoolText(v.text)
+	g.bools = g.bools && (v.kind == kindText || v.kind == kindBool) && isBool
+
+	if v.kind != kindText && v.kind != kindString {
+		g.layouts = nil
+	}
+	var layouts []string
+	for _, layout := range g.layouts {
+		if _, err := time.Parse(layout, v.text); err == nil {
+			layouts = append(layouts, layout)
+		}
+	}
+	g.layouts = layouts
+}
+
+// result returns the narrowest type and date layout matching every value
+func (g *columnGuess) result() (ColumnType, string) {
+	switch {
+	case !g.seen:
+		return ColumnString, ""
+	case g.ints:
+		return ColumnInt, ""
+	case g.floats:
+		return ColumnFloat, ""
+	case g.bools:
+		return ColumnBool, ""
+	case len(g.layouts) > 0:
+		return ColumnDate, g.layouts[0]
+	default:
+		return ColumnString, ""
+	}
+}
+
+// isPlainInt reports whether s is an integer Excel stores exactly, without
+// leading zeros that would be lost, such as in ZIP codes
+func isPlainInt(s string) bool {
+	digits := trimSign(s)
+	if digits == "" || len(digits) > maxImportDigits || (digits[0] == '0' && len(digits) > 1) {
+		return false
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// isPlainFloat reports whether s is a plain integer or a finite decimal
+// number such as "-1.5" or "2.5e3", without leading zeros
+func isPlainFloat(s string) bool {
+	if isPlainInt(s) {
+		return true
+	}
+
+	number := trimSign(s)
+	mantissa, hasExp := number, false
+	if i := strings.IndexAny(number, "eE"); i >= 0 {
+		mantissa, hasExp = number[:i], true
+	}
+	intPart, _, hasPoint := strings.Cut(mantissa, ".")
+	if !hasPoint && !hasExp {
+		return false
+	}
+	if len(intPart) > 1 && intPart[0] == '0' {
+		return false
+	}
+	for i := 0; i < len(number); i++ {
+		if c := number[i]; !(c >= '0' && c <= '9' || c == '.' || c == '-' || c == '+' || c == 'e' || c == 'E') {
+			return false
+		}
+	}
+	return isFiniteFloat(s)
+}
+
+// trimSign removes one leading sign from a number
+func trimSign(s string) string {
+	if s != "" && (s[0] == '-' || s[0] == '+') {
+		return s[1:]
+	}
+	return s
+}
+
+// isFiniteFloat reports whether s parses as a finite decimal number
+func isFiniteFloat(s string) bool {
+	f, err := strconv.ParseFloat(s, 64)
+	return err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) && !strings.ContainsAny(s, "xX_")
+}
+
+// parseBoolText parses "true" or "false" in any case, the only forms a
+// boolean column accepts
+func parseBoolText(s string) (value, ok bool) {
+	switch {
+	case strings.EqualFold(s, "true"):
+		return true, true
+	case strings.EqualFold(s, "false"):
+		return false, true
+	}
+	return false, false
+}
+
+// newImportColumns returns the converters of the columns named by names,
+// applying declared columns and inferring the types of the others from the
+// sample. Declared columns with a name are looked up by name when byName is
+// set, and by position otherwise.
+func newImportColumns(w *Writer, names []string, declared []ImportColumn, byName bool, sample []importRecord, layouts []string) ([]*importColumn, error) {
+	if len(layouts) == 0 {
+		layouts = defaultDateLayouts
+	}
+
+	columns := make([]*importColumn, len(names))
+	for i, name := range names {
+		c := &importColumn{name: name, label: name, layouts: layouts}
+		if c.label == "" {
+			c.label = columnName(i)
+		}
+
+		var decl *ImportColumn
+		if byName {
+			for j := range declared {
+				if declared[j].Name == name {
+					decl = &declared[j]
+				}
+			}
+		} else if i < len(declared) {
+			decl = &declared[i]
+			if decl.Name != "" {
+				c.name = decl.Name
+				c.label = decl.Name
+			}
+		}
+
+		if decl != nil {
+			if decl.Type < ColumnAuto || decl.Type > ColumnDate {
+				return nil, fmt.Errorf("column %s: unknown column type %d", c.label, decl.Type)
+			}
+			if decl.StyleID < 0 || decl.StyleID >= w.styles.cellStyleCount() {
+				return nil, fmt.Errorf("column %s: unknown style ID %d", c.label, decl.StyleID)
+			}
+			c.typ = decl.Type
+			c.declared = decl.Type != ColumnAuto
+			c.style = decl.StyleID
+		}
+
+		if c.typ == ColumnAuto {
+			guess := newColumnGuess(layouts)
+			for _, rec := range sample {
+				if i < len(rec.values) {
+					guess.observe(rec.values[i])
+				}
+			}
+			c.typ, c.layout = guess.result()
+		}
+
+		if c.typ == ColumnDate && c.style == 0 {
+			id, err := w.styles.addCellStyle(Style{NumFmt: c.dateFormat()})
+			if err != nil {
+				return nil, err
+			}
+			c.style = id
+		}
+		columns[i] = c
+	}
+	return columns, nil
+}
+
+// dateFormat returns the number format of a date column: a date and time
+// when any of its layouts has a time of day
+func (c *importColumn) dateFormat() string {
+	layouts := c.layouts
+	if c.layout != "" {
+		layouts = []string{c.layout}
+	}
+	for _, layout := range layouts {
+		// Only the hour, minute and second elements of a layout contain the
+		// digits 3, 4 and 5
+		if strings.ContainsAny(layout, "345") {
+			return defaultDateTimeFormat
+		}
+	}
+	return "yyyy-mm-dd"
+}
+
+// convert returns the cell value of v. Values of a declared type that do
+// not parse are errors; other values that do not match the column type are
+// written as text.
+func (c *importColumn) convert(v importValue) (interface{}, error) {
+	if v.kind == kindNull {
+		if c.style != 0 {
+			return Cell{StyleID: c.style}, nil
+		}
+		return nil, nil
+	}
+
+	value, err := c.parse(v)
+	if err != nil {
+		if c.declared {
+			return nil, err
+		}
+		value = v.text
+	}
+	if c.style != 0 {
+		return Cell{Value: value, StyleID: c.style}, nil
+	}
+	return value, nil
+}
+
+// parse converts v to the column type. Inferred columns only accept values
+// of the kind and form they were inferred from, so e.g. leading zeros are
+// kept as text; declared columns accept anything that parses.
+func (c *importColumn) parse(v importValue) (interface{}, error) {
+	strict := !c.declared
+	switch c.typ {
+	case ColumnInt:
+		if !strict || (v.kind != kindString && isPlainInt(v.text)) {
+			if n, err := strconv.ParseInt(v.text, 10, 64); err == nil {
+				return n, nil
+			}
+		}
+	case ColumnFloat:
+		if !strict || (v.kind != kindString && isPlainFloat(v.text)) {
+			if isFiniteFloat(v.text) {
+				f, _ := strconv.ParseFloat(v.text, 64)
+				return f, nil
+			}
+		}
+	case ColumnBool:
+		if !strict || v.kind != kindString {
+			if b, ok := parseBoolText(v.text); ok {
+				return b, nil
+			}
+		}
+	case ColumnDate:
+		if !strict || v.kind == kindText || v.kind == kindString {
+			layouts := c.layouts
+			if c.layout != "" {
+				layouts = []string{c.layout}
+			}
+			for _, layout := range layouts {
+				if t, err := time.Parse(layout, v.text); err == nil {
+					return timeToSerial(t), nil
+				}
+			}
+		}
+	default:
+		return v.text, nil
+	}
+	return nil, fmt.Errorf("cannot parse %q as %s", v.text, c.typ)
+}
+
+// importRows converts records into rows and writes them in batches
+type importRows struct {
+	w       *Writer
+	columns []*importColumn
+	rows    [][]interface{}
+}
+
+// start starts the file with the header row, or writes the header as a row
+// when the Writer has already been started
+func (ir *importRows) start(header bool) error {
+	var headers []interface{}
+	if header {
+		for _, c := range ir.columns {
+			headers = append(headers, c.name)
+		}
+	}
+	if !ir.w.started {
+		return ir.w.StartFile(headers)
+	}
+	if len(headers) > 0 {
+		return ir.w.WriteRow(headers)
+	}
+	return nil
+}
+
+// add converts a record and writes the pending rows once a batch is full
+func (ir *importRows) add(rec importRecord) error {
+	var row []interface{}
+	if n := len(ir.rows); n < cap(ir.rows) && len(ir.rows[:n+1][n]) == len(ir.columns) {
+		// Reuse a row of an earlier batch
+		row = ir.rows[:n+1][n]
+	} else {
+		row = make([]interface{}, len(ir.columns))
+	}
+
+	for i, c := range ir.columns {
+		v := importValue{kind: kindNull}
+		if i < len(rec.values) {
+			v = rec.values[i]
+		}
+		value, err := c.convert(v)
+		if err != nil {
+			return &ImportError{Line: rec.line, Column: c.label, Err: err}
+		}
+		row[i] = value
+	}
+
+	ir.rows = append(ir.rows, row)
+	if len(ir.rows) == importBatchRows {
+		return ir.flush()
+	}
+	return nil
+}
+
+// flush writes the pending rows
+func (ir *importRows) flush() error {
+	if len(ir.rows) == 0 {
+		return nil
+	}
+	err := ir.w.WriteRows(ir.rows)
+	ir.rows = ir.rows[:0]
+	if err != nil {
+		return fmt.Errorf("failed to write rows: %w", err)
+	}
+	return nil
+}
+
+// newDecoder returns a reader of r's text as UTF-8, without a byte order mark
+func newDecoder(r io.Reader, enc Encoding) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	bom, err := br.Peek(3)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	hasBOM := func(mark string) bool {
+		if strings.HasPrefix(string(bom), mark) {
+			br.Discard(len(mark))
+			return true
+		}
+		return false
+	}
+
+	switch enc {
+	case EncodingAuto:
+		switch {
+		case hasBOM("\xff\xfe"):
+			return &decodingReader{src: br, next: readUTF16(br, littleEndian)}, nil
+		case hasBOM("\xfe\xff"):
+			return &decodingReader{src: br, next: readUTF16(br, bigEndian)}, nil
+		}
+		hasBOM("\xef\xbb\xbf")
+		return br, nil
+	case EncodingUTF8:
+		hasBOM("\xef\xbb\xbf")
+		return br, nil
+	case EncodingUTF16LE:
+		hasBOM("\xff\xfe")
+		return &decodingReader{src: br, next: readUTF16(br, littleEndian)}, nil
+	case EncodingUTF16BE:
+		hasBOM("\xfe\xff")
+		return &decodingReader{src: br, next: readUTF16(br, bigEndian)}, nil
+	case EncodingLatin1:
+		return &decodingReader{src: br, next: func() (rune, error) {
+			b, err := br.ReadByte()
+			return rune(b), err
+		}}, nil
+	default:
+		return nil, fmt.Errorf("unknown encoding %d", enc)
+	}
+}
+
+// Byte orders of UTF-16 input
+const (
+	littleEndian = false
+	bigEndian    = true
+)
+
+// readUTF16 returns a function reading the next character of UTF-16 input.
+// Unpaired surrogates are read as U+FFFD.
+func readUTF16(br *bufio.Reader, big bool) func() (rune, error) {
+	decode := func(b []byte) rune {
+		if big {
+			return rune(b[0])<<8 | rune(b[1])
+		}
+		return rune(b[1])<<8 | rune(b[0])
+	}
+
+	return func() (rune, error) {
+		var b [2]byte
+		if _, err := io.ReadFull(br, b[:]); err != nil {
+			return 0, err
+		}
+		r := decode(b[:])
+		if !utf16.IsSurrogate(r) {
+			return r, nil
+		}
+
+		// Only a high surrogate followed by a low one is a valid pair
+		next, err := br.Peek(2)
+		if r >= 0xdc00 || err != nil {
+			return utf8.RuneError, nil
+		}
+		pair := utf16.DecodeRune(r, decode(next))
+		if pair == utf8.RuneError {
+			return pair, nil
+		}
+		br.Discard(2)
+		return pair, nil
+	}
+}
+
+// decodingReader converts characters read by next into UTF-8
+type decodingReader struct {
+	src     *bufio.Reader
+	next    func() (rune, error)
+	pending []byte // Encoded bytes that did not fit in the last Read
+}
+
+// Read fills p with UTF-8 text
+func (d *decodingReader) Read(p []byte) (int, error) {
+	n := copy(p, d.pending)
+	d.pending = d.pending[n:]
+
+	for n < len(p) {
+		r, err := d.next()
+		if err != nil {
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				err = fmt.Errorf("input ends in the middle of a character")
+			}
+			if n > 0 && err == io.EOF {
+				return n, nil
+			}
+			return n, err
+		}
+
+		var buf [utf8.UTFMax]byte
+		size := utf8.EncodeRune(buf[:], r)
+		copied := copy(p[n:], buf[:size])
+		n += copied
+		if copied < size {
+			d.pending = append(d.pending[:0], buf[copied:size]...)
+		}
+	}
+	return n, nil
+}
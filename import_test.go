@@ -0,0 +1,138 @@
+package kolayxlsxstream
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"unicode/utf16"
+)
+
+// utf16Bytes encodes s as UTF-16 with a byte order mark
+func utf16Bytes(s string, big bool) []byte {
+	var out []byte
+	for _, u := range append([]uint16{0xfeff}, utf16.Encode([]rune(s))...) {
+		if big {
+			out = append(out, byte(u>>8), byte(u))
+		} else {
+			out = append(out, byte(u), byte(u>>8))
+		}
+	}
+	return out
+}
+
+func TestImportEncodings(t *testing.T) {
+	text := "Şehir,Emoji\nİstanbul,🎉\n"
+	tests := []struct {
+		name     string
+		input    string
+		encoding Encoding
+		expected string
+	}{
+		{"utf-8 bom", "\xef\xbb\xbf" + text, EncodingAuto, text},
+		{"utf-8", text, EncodingUTF8, text},
+		{"utf-16le bom", string(utf16Bytes(text, false)), EncodingAuto, text},
+		{"utf-16be bom", string(utf16Bytes(text, true)), EncodingAuto, text},
+		{"utf-16le", string(utf16Bytes(text, false)[2:]), EncodingUTF16LE, text},
+		{"unpaired surrogate", "\x00\xd8A\x00", EncodingUTF16LE, "�A"},
+		{"latin-1", "Caf\xe9,na\xefve\n", EncodingLatin1, "Café,naïve\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := newDecoder(strings.NewReader(tt.input), tt.encoding)
+			if err != nil {
+				t.Fatalf("Failed to create decoder: %v", err)
+			}
+			// Read one byte at a time to split multi-byte characters
+			var out []byte
+			buf := make([]byte, 1)
+			for {
+				n, err := r.Read(buf)
+				out = append(out, buf[:n]...)
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Failed to read: %v", err)
+				}
+			}
+			if string(out) != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, out)
+			}
+		})
+	}
+
+	r, err := newDecoder(strings.NewReader("\x41\x00\x42"), EncodingUTF16LE)
+	if err != nil {
+		t.Fatalf("Failed to create decoder: %v", err)
+	}
+	if _, err := io.ReadAll(r); err == nil {
+		t.Error("Expected error for UTF-16 input with an odd number of bytes")
+	}
+	if _, err := newDecoder(strings.NewReader(""), Encoding(99)); err == nil {
+		t.Error("Expected error for an unknown encoding")
+	}
+}
+
+func TestImportIntoStartedWriter(t *testing.T) {
+	tmpFile := "test_import_started.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 1500
+	writer := NewWriter(sink, config)
+	if err := writer.StartFile([]interface{}{"Title"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	var input strings.Builder
+	input.WriteString("Name,Value\n")
+	for i := 0; i < 2000; i++ {
+		input.WriteString("row,1.5\n")
+	}
+	utf16Input := utf16Bytes(input.String(), false)
+	if err := ImportCSV(writer, strings.NewReader(string(utf16Input)), CSVOptions{SampleRows: 10}); err != nil {
+		t.Fatalf("Failed to import CSV: %v", err)
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	// The header is written as a row after the writer was started
+	if stats.TotalRows != 2001 || stats.TotalSheets != 2 {
+		t.Errorf("Expected 2001 rows on 2 sheets, got %d rows on %d sheets", stats.TotalRows, stats.TotalSheets)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>Title</t></is></c></row>`,
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>Name</t></is></c>`,
+		`<row r="3"><c r="A3" t="inlineStr"><is><t>row</t></is></c><c r="B3"><v>1.5</v></c></row>`,
+	} {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+}
+
+func TestInferNumbers(t *testing.T) {
+	ints := map[string]bool{"0": true, "-12": true, "+7": true, "007": false, "1.0": false, "": false, "-": false, "-+5": false, "123456789012345": true, "1234567890123456": false}
+	for s, expected := range ints {
+		if got := isPlainInt(s); got != expected {
+			t.Errorf("isPlainInt(%q) = %v, expected %v", s, got, expected)
+		}
+	}
+
+	floats := map[string]bool{"1": true, "-1.5": true, ".5": true, "2.5e3": true, "1E-7": true, "0.25": true, "00.5": false, "1e400": false, "NaN": false, "Inf": false, "0x1p3": false, "1_000.5": false, "1,5": false, " 1.5": false}
+	for s, expected := range floats {
+		if got := isPlainFloat(s); got != expected {
+			t.Errorf("isPlainFloat(%q) = %v, expected %v", s, got, expected)
+		}
+	}
+}
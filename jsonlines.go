@@ -0,0 +1,253 @@
+package kolayxlsxstream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// JSONOptions controls how ImportJSONLines reads its input
+type JSONOptions struct {
+	Encoding Encoding
+
+	// NoHeader leaves out the header row of column names
+	NoHeader bool
+
+	// Columns declares the keys written as columns, in order, with their
+	// types and styles. When empty, the columns are the keys of the sampled
+	// objects in the order they first appear, with inferred types.
+	Columns []ImportColumn
+
+	// SampleRows is the number of objects read before writing, to find the
+	// columns and infer their types (default: 1000). Without declared
+	// Columns, a key first seen after the sample is an error.
+	SampleRows int
+
+	// DateLayouts are the time.Parse layouts of date strings, tried in order
+	// (default: ISO 8601 dates and date-times)
+	DateLayouts []string
+}
+
+// ImportJSONLines writes one row per JSON object of a JSON Lines file. Numbers,
+// booleans and strings are written as such, strings matching a date layout
+// in every sampled row as dates, nested objects and arrays as their JSON
+// text, and null, empty or missing values as empty cells. Columns mixing
+// kinds of values are written as text. Keys that are not declared columns
+// are ignored, and blank lines are skipped.
+//
+// ImportJSONLines starts the Writer with the header row if it has not been
+// started, and otherwise writes the header as a row. It does not finish the
+// file. Errors in the input are returned as *ImportError.
+func ImportJSONLines(w *Writer, r io.Reader, opts JSONOptions) error {
+	if opts.SampleRows < 0 {
+		return fmt.Errorf("sample rows must not be negative")
+	}
+	if opts.SampleRows == 0 {
+		opts.SampleRows = defaultSampleRows
+	}
+	for i, c := range opts.Columns {
+		if c.Name == "" {
+			return fmt.Errorf("column %d has no name", i+1)
+		}
+	}
+
+	decoded, err := newDecoder(r, opts.Encoding)
+	if err != nil {
+		return err
+	}
+	jr := &jsonLinesReader{r: bufio.NewReader(decoded)}
+
+	var names []string
+	for _, c := range opts.Columns {
+		names = append(names, c.Name)
+	}
+	discover := len(names) == 0
+	known := map[string]bool{}
+
+	var objects []jsonObject
+	for len(objects) < opts.SampleRows {
+		obj, err := jr.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if discover {
+			for _, key := range obj.keys {
+				if !known[key] {
+					known[key] = true
+					names = append(names, key)
+				}
+			}
+		}
+		objects = append(objects, obj)
+	}
+	sample := make([]importRecord, len(objects))
+	for i, obj := range objects {
+		sample[i] = obj.record(names)
+	}
+	columns, err := newImportColumns(w, names, opts.Columns, true, sample, opts.DateLayouts)
+	if err != nil {
+		return err
+	}
+
+	ir := &importRows{w: w, columns: columns}
+	if err := ir.start(!opts.NoHeader); err != nil {
+		return err
+	}
+
+	for _, rec := range sample {
+		if err := ir.add(rec); err != nil {
+			return err
+		}
+	}
+	for {
+		obj, err := jr.next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if discover {
+			for _, key := range obj.keys {
+				if !known[key] {
+					return &ImportError{Line: obj.line, Column: key, Err: fmt.Errorf("key is not in the first %d objects; declare Columns or raise SampleRows", opts.SampleRows)}
+				}
+			}
+		}
+		if err := ir.add(obj.record(names)); err != nil {
+			return err
+		}
+	}
+	return ir.flush()
+}
+
+// jsonObject is one line of JSON Lines input
+type jsonObject struct {
+	line   int
+	keys   []string // Keys in input order
+	values map[string]importValue
+}
+
+// record returns the object's values of the named columns
+func (o jsonObject) record(names []string) importRecord {
+	rec := importRecord{line: o.line, values: make([]importValue, len(names))}
+	for i, name := range names {
+		if v, ok := o.values[name]; ok {
+			rec.values[i] = v
+		} else {
+			rec.values[i] = importValue{kind: kindNull}
+		}
+	}
+	return rec
+}
+
+// jsonLinesReader reads one JSON object per line
+type jsonLinesReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+// next returns the next object, skipping blank lines
+func (jr *jsonLinesReader) next() (jsonObject, error) {
+	for {
+		data, err := jr.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			if errors.Is(err, io.EOF) {
+				return jsonObject{}, io.EOF
+			}
+			return jsonObject{}, fmt.Errorf("failed to read JSON Lines: %w", err)
+		}
+		if err != nil && !errors.Is(err, io.EOF) {
+			return jsonObject{}, fmt.Errorf("failed to read JSON Lines: %w", err)
+		}
+		jr.line++
+
+		data = bytes.TrimSpace(data)
+		if len(data) == 0 {
+			continue
+		}
+		obj, perr := parseJSONObject(data)
+		if perr != nil {
+			return jsonObject{}, &ImportError{Line: jr.line, Err: perr}
+		}
+		obj.line = jr.line
+		return obj, nil
+	}
+}
+
+// parseJSONObject parses a line holding a single JSON object
+func parseJSONObject(data []byte) (jsonObject, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil || tok != json.Delim('{') {
+		return jsonObject{}, fmt.Errorf("line is not a JSON object")
+	}
+
+	obj := jsonObject{values: map[string]importValue{}}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return jsonObject{}, fmt.Errorf("invalid JSON: %w", err)
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return jsonObject{}, fmt.Errorf("invalid JSON object key %v", tok)
+		}
+
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return jsonObject{}, fmt.Errorf("invalid JSON value of %q: %w", key, err)
+		}
+		v, err := jsonValue(raw)
+		if err != nil {
+			return jsonObject{}, fmt.Errorf("invalid JSON value of %q: %w", key, err)
+		}
+
+		if _, dup := obj.values[key]; !dup {
+			obj.keys = append(obj.keys, key)
+		}
+		obj.values[key] = v
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return jsonObject{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return jsonObject{}, fmt.Errorf("unexpected data after the JSON object")
+	}
+	return obj, nil
+}
+
+// jsonValue returns the import value of a JSON value
+func jsonValue(raw json.RawMessage) (importValue, error) {
+	switch raw[0] {
+	case 'n':
+		return importValue{kind: kindNull}, nil
+	case 't', 'f':
+		return importValue{text: string(raw), kind: kindBool}, nil
+	case '"':
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return importValue{}, err
+		}
+		if s == "" {
+			// Empty strings are empty cells, as in CSV input
+			return importValue{kind: kindNull}, nil
+		}
+		return importValue{text: s, kind: kindString}, nil
+	case '{', '[':
+		var compact bytes.Buffer
+		if err := json.Compact(&compact, raw); err != nil {
+			return importValue{}, err
+		}
+		return importValue{text: compact.String(), kind: kindRaw}, nil
+	default:
+		// Numbers keep their text, so integers are exact
+		return importValue{text: string(raw), kind: kindNumber}, nil
+	}
+}
@@ -0,0 +1,158 @@
+package kolayxlsxstream
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestImportJSONLines(t *testing.T) {
+	tmpFile := "test_import_jsonl.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := NewWriter(sink)
+
+	input := `{"id": 1, "name": "Tom & Jerry", "score": 85.5, "active": true, "joined": "2024-01-15T09:30:00Z", "tags": ["a", "b"]}` + "\n" +
+		"\n" +
+		`{"id": 2, "name": null, "score": 92, "active": false, "joined": "2024-02-29T00:00:00+03:00", "zip": "01234", "mixed": 1}` + "\n" +
+		`{"active": true, "id": 12345678901234567, "mixed": "one"}` + "\n"
+	if err := ImportJSONLines(writer, strings.NewReader(input), JSONOptions{SampleRows: 2}); err != nil {
+		t.Fatalf("Failed to import JSON Lines: %v", err)
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 3 {
+		t.Errorf("Expected 3 rows, got %d", stats.TotalRows)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		// Columns in the order keys first appear in the sample
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>id</t></is></c><c r="B1" t="inlineStr"><is><t>name</t></is></c>` +
+			`<c r="C1" t="inlineStr"><is><t>score</t></is></c><c r="D1" t="inlineStr"><is><t>active</t></is></c>` +
+			`<c r="E1" t="inlineStr"><is><t>joined</t></is></c><c r="F1" t="inlineStr"><is><t>tags</t></is></c>` +
+			`<c r="G1" t="inlineStr"><is><t>zip</t></is></c><c r="H1" t="inlineStr"><is><t>mixed</t></is></c></row>`,
+		`<row r="2"><c r="A2"><v>1</v></c><c r="B2" t="inlineStr"><is><t>Tom &amp; Jerry</t></is></c><c r="C2"><v>85.5</v></c>` +
+			`<c r="D2" t="b"><v>1</v></c><c r="E2" s="2"><v>45306.395833333336</v></c><c r="F2" t="inlineStr"><is><t>[&#34;a&#34;,&#34;b&#34;]</t></is></c>`,
+		`<c r="B3"/><c r="C3"><v>92</v></c><c r="D3" t="b"><v>0</v></c><c r="E3" s="2"><v>45351</v></c><c r="F3"/><c r="G3" t="inlineStr"><is><t>01234</t></is></c><c r="H3"><v>1</v></c></row>`,
+		// After the sample, integers too long for Excel are text in a
+		// numeric column, and strings in a numeric one
+		`<row r="4"><c r="A4" t="inlineStr"><is><t>12345678901234567</t></is></c>`,
+		`<c r="H4" t="inlineStr"><is><t>one</t></is></c></row>`,
+	} {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+}
+
+func TestImportJSONLinesDeclaredColumns(t *testing.T) {
+	tmpFile := "test_import_jsonl_declared.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := NewWriter(sink)
+
+	input := `{"amount": "12.50", "id": "A-1", "ignored": true}` + "\n" +
+		`{"id": "A-2"}` + "\n"
+	err = ImportJSONLines(writer, strings.NewReader(input), JSONOptions{
+		NoHeader: true,
+		Columns:  []ImportColumn{{Name: "id"}, {Name: "amount", Type: ColumnFloat}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import JSON Lines: %v", err)
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>A-1</t></is></c><c r="B1"><v>12.5</v></c></row>`,
+		`<row r="2"><c r="A2" t="inlineStr"><is><t>A-2</t></is></c><c r="B2"/></row>`,
+	} {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+}
+
+func TestImportJSONLinesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		opts   JSONOptions
+		line   int
+		column string
+		errMsg string
+	}{
+		{
+			name:   "not an object",
+			input:  "{\"a\": 1}\n\n[1, 2]\n",
+			line:   3,
+			errMsg: "line 3: line is not a JSON object",
+		},
+		{
+			name:   "invalid JSON",
+			input:  "{\"a\": 1}\n{\"a\": }\n",
+			line:   2,
+			errMsg: `line 2: invalid JSON value of "a": invalid character '}' looking for beginning of value`,
+		},
+		{
+			name:   "trailing data",
+			input:  "{\"a\": 1} {\"a\": 2}\n",
+			line:   1,
+			errMsg: "line 1: unexpected data after the JSON object",
+		},
+		{
+			name:   "declared type",
+			input:  "{\"n\": 1}\n{\"n\": true}\n",
+			opts:   JSONOptions{Columns: []ImportColumn{{Name: "n", Type: ColumnInt}}},
+			line:   2,
+			column: "n",
+			errMsg: `line 2, column n: cannot parse "true" as int`,
+		},
+		{
+			name:   "key after the sample",
+			input:  "{\"a\": 1}\n{\"a\": 2}\n{\"a\": 3, \"b\": 4}\n",
+			opts:   JSONOptions{SampleRows: 2},
+			line:   3,
+			column: "b",
+			errMsg: "line 3, column b: key is not in the first 2 objects; declare Columns or raise SampleRows",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := NewWriter(discardSink{})
+			err := ImportJSONLines(writer, strings.NewReader(tt.input), tt.opts)
+
+			var importErr *ImportError
+			if !errors.As(err, &importErr) {
+				t.Fatalf("Expected an ImportError, got %v", err)
+			}
+			if importErr.Line != tt.line || importErr.Column != tt.column {
+				t.Errorf("Expected line %d column %q, got line %d column %q", tt.line, tt.column, importErr.Line, importErr.Column)
+			}
+			if err.Error() != tt.errMsg {
+				t.Errorf("Expected error %q, got %q", tt.errMsg, err.Error())
+			}
+		})
+	}
+
+	writer := NewWriter(discardSink{})
+	if err := ImportJSONLines(writer, strings.NewReader(""), JSONOptions{Columns: []ImportColumn{{Type: ColumnInt}}}); err == nil {
+		t.Error("Expected error for a declared column without a name")
+	}
+}
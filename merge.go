@@ -0,0 +1,93 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// MergeCells merges a range of cells (e.g. "A1:D1") on the current sheet.
+// The range may cover rows that are already written or still to be written,
+// but must fit within the sheet and must not overlap another merged range.
+func (w *Writer) MergeCells(ref string) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+
+	r, err := parseCellRange(ref)
+	if err != nil {
+		return err
+	}
+	return w.current.addMerge(r, w.sheetCapacity())
+}
+
+// MergeCurrentRow merges the zero-based columns firstCol to lastCol of the
+// row most recently written to the current sheet, e.g. for a title row
+func (w *Writer) MergeCurrentRow(firstCol, lastCol int) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+
+	sw := w.current
+	if sw.lastRow == 0 {
+		return fmt.Errorf("no row written to the current sheet")
+	}
+	if firstCol < 0 || lastCol < firstCol || lastCol >= maxColumns {
+		return fmt.Errorf("invalid column span %d-%d", firstCol, lastCol)
+	}
+
+	row := sw.lastRow - 1
+	return sw.addMerge(cellRange{fromRow: row, fromCol: firstCol, toRow: row, toCol: lastCol}, w.sheetCapacity())
+}
+
+// addMerge validates a merged range against the sheet's capacity in rows and
+// records it for the sheet's <mergeCells> element
+func (sw *sheetWriter) addMerge(r cellRange, capacity int) error {
+	if r.fromRow == r.toRow && r.fromCol == r.toCol {
+		return fmt.Errorf("merge range %s must span more than one cell", r)
+	}
+	if r.toRow >= capacity {
+		return fmt.Errorf("merge range %s exceeds the sheet limit of %d rows", r, capacity)
+	}
+
+	// Merges are kept sorted by first row, so only ranges starting at most
+	// mergeMaxHeight rows above r can reach it
+	i := sort.Search(len(sw.merges), func(i int) bool {
+		return sw.merges[i].fromRow > r.fromRow
+	})
+	for j := i - 1; j >= 0 && sw.merges[j].fromRow+sw.mergeMaxHeight >= r.fromRow; j-- {
+		if sw.merges[j].overlaps(r) {
+			return fmt.Errorf("merge range %s overlaps %s", r, sw.merges[j])
+		}
+	}
+	for j := i; j < len(sw.merges) && sw.merges[j].fromRow <= r.toRow; j++ {
+		if sw.merges[j].overlaps(r) {
+			return fmt.Errorf("merge range %s overlaps %s", r, sw.merges[j])
+		}
+	}
+
+	sw.merges = append(sw.merges, cellRange{})
+	copy(sw.merges[i+1:], sw.merges[i:])
+	sw.merges[i] = r
+	sw.mergeMaxHeight = max(sw.mergeMaxHeight, r.toRow-r.fromRow)
+
+	return nil
+}
+
+// writeMerges writes the sheet's <mergeCells> element
+func (sw *sheetWriter) writeMerges() error {
+	if len(sw.merges) == 0 {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(sw.writer, `<mergeCells count="%d">`, len(sw.merges)); err != nil {
+		return err
+	}
+	for _, r := range sw.merges {
+		if _, err := fmt.Fprintf(sw.writer, `<mergeCell ref="%s"/>`, r); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(sw.writer, "</mergeCells>\n")
+	return err
+}
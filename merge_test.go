@@ -0,0 +1,159 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCellRange(t *testing.T) {
+	tests := []struct {
+		ref      string
+		expected string
+		valid    bool
+	}{
+		{"A1:D1", "A1:D1", true},
+		{"$B$2:$C$10", "B2:C10", true},
+		{"D4:A1", "A1:D4", true},
+		{"xfd1048576", "XFD1048576:XFD1048576", true},
+		{"A0", "", false},
+		{"A1048577", "", false},
+		{"XFE1", "", false},
+		{"1A", "", false},
+		{"A", "", false},
+		{"A1:B", "", false},
+	}
+
+	for _, tt := range tests {
+		r, err := parseCellRange(tt.ref)
+		if tt.valid && err != nil {
+			t.Errorf("Unexpected error for %q: %v", tt.ref, err)
+			continue
+		}
+		if !tt.valid {
+			if err == nil {
+				t.Errorf("Expected error for %q", tt.ref)
+			}
+			continue
+		}
+		if r.String() != tt.expected {
+			t.Errorf("parseCellRange(%q) = %s, expected %s", tt.ref, r, tt.expected)
+		}
+	}
+}
+
+func TestMergeCells(t *testing.T) {
+	tmpFile := "test_merge.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 10
+	writer := NewWriter(sink, config)
+
+	if err := writer.MergeCells("A1:D1"); err == nil {
+		t.Error("Expected error when merging before starting file")
+	}
+
+	if err := writer.StartFile([]interface{}{"Quarterly Report"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	if err := writer.MergeCurrentRow(0, 3); err != nil {
+		t.Fatalf("Failed to merge title row: %v", err)
+	}
+
+	// Pending rows may be merged before they are written
+	if err := writer.MergeCells("A3:A5"); err != nil {
+		t.Fatalf("Failed to merge pending rows: %v", err)
+	}
+
+	invalid := []string{
+		"B1:C1",   // overlaps title
+		"A4:B4",   // overlaps A3:A5
+		"A2",      // single cell
+		"A2:A2",   // single cell
+		"A10:A11", // beyond MaxRowsPerSheet
+		"A2:",     // malformed
+	}
+	for _, ref := range invalid {
+		if err := writer.MergeCells(ref); err == nil {
+			t.Errorf("Expected error for merge range %q", ref)
+		}
+	}
+
+	if err := writer.MergeCells("B3:D3"); err != nil {
+		t.Fatalf("Failed to merge adjacent range: %v", err)
+	}
+
+	for i := 0; i < 12; i++ {
+		if err := writer.WriteRow([]interface{}{i, i, i, i}); err != nil {
+			t.Fatalf("Failed to write row %d: %v", i, err)
+		}
+	}
+
+	// The second sheet has its own merges
+	if err := writer.MergeCells("A1:D1"); err != nil {
+		t.Fatalf("Failed to merge on second sheet: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := `</sheetData>
+<mergeCells count="3"><mergeCell ref="A1:D1"/><mergeCell ref="A3:A5"/><mergeCell ref="B3:D3"/></mergeCells>
+</worksheet>`
+	if !strings.Contains(sheet1, expected) {
+		t.Errorf("Expected %s in sheet 1, got %s", expected, sheet1)
+	}
+
+	sheet2 := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	if !strings.Contains(sheet2, `<mergeCells count="1"><mergeCell ref="A1:D1"/></mergeCells>`) {
+		t.Errorf("Expected merged range in sheet 2, got %s", sheet2)
+	}
+}
+
+func TestMergeCellsTotalsRow(t *testing.T) {
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	config.SheetOptions.Table = &Table{TotalsRow: true}
+	writer := NewWriter(discardSink{}, config)
+	if err := writer.StartFile([]interface{}{"ID"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	// The last row of the sheet is kept for the totals row
+	err := writer.MergeCells("A2:A3")
+	if err == nil || !strings.Contains(err.Error(), "limit of 2 rows") {
+		t.Errorf("Expected sheet limit error for merge over the totals row, got %v", err)
+	}
+	if err := writer.MergeCells("A1:A2"); err != nil {
+		t.Errorf("Failed to merge data rows: %v", err)
+	}
+}
+
+func TestMergeManyRows(t *testing.T) {
+	sw := newTestSheetWriter(nil)
+
+	for row := 0; row < 50000; row++ {
+		if err := sw.addMerge(cellRange{fromRow: row, fromCol: 0, toRow: row, toCol: 2}, sw.config.MaxRowsPerSheet); err != nil {
+			t.Fatalf("Failed to merge row %d: %v", row, err)
+		}
+	}
+
+	if err := sw.addMerge(cellRange{fromRow: 100, fromCol: 2, toRow: 100, toCol: 5}, sw.config.MaxRowsPerSheet); err == nil {
+		t.Error("Expected overlap error")
+	}
+	if err := sw.addMerge(cellRange{fromRow: 100, fromCol: 3, toRow: 200, toCol: 5}, sw.config.MaxRowsPerSheet); err != nil {
+		t.Errorf("Unexpected error for non-overlapping range: %v", err)
+	}
+	if err := sw.addMerge(cellRange{fromRow: 150, fromCol: 4, toRow: 150, toCol: 6}, sw.config.MaxRowsPerSheet); err == nil {
+		t.Error("Expected overlap error with tall range")
+	}
+}
@@ -0,0 +1,161 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Built-in names used for printing
+const (
+	builtinNamePrefix = "_xlnm."
+	printTitlesName   = builtinNamePrefix + "Print_Titles"
+	printAreaName     = builtinNamePrefix + "Print_Area"
+)
+
+// r1c1Name matches names Excel would read as R1C1 references, such as "R", "C" or "R2C3"
+var r1c1Name = regexp.MustCompile(`^(?i)(r\d*)?(c\d*)?$`)
+
+// definedName is a name registered for the workbook's <definedNames> block
+type definedName struct {
+	name       string
+	scopeSheet int        // 1-based sheet the name is local to; 0 for the workbook
+	refersTo   string     // Fixed formula
+	dataRange  *DataRange // Range resolved when the file is finished
+	builtin    bool       // Whether the name is one of Excel's _xlnm. names
+}
+
+// DefineName defines a name for a formula or range, such as "SalesData"
+// referring to "Sheet1!$A$2:$C$100". scopeSheet limits the name to a sheet
+// (1-based); 0 defines it for the whole workbook.
+func (w *Writer) DefineName(name, refersTo string, scopeSheet int) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+	refersTo = strings.TrimPrefix(strings.TrimSpace(refersTo), "=")
+	if refersTo == "" {
+		return fmt.Errorf("defined name %q must refer to a formula or range", name)
+	}
+	return w.addDefinedName(definedName{name: name, scopeSheet: scopeSheet, refersTo: refersTo})
+}
+
+// DefineNameRange defines a name for a range whose rows left at zero are
+// resolved when the file is finished, like chart ranges. scopeSheet limits
+// the name to a sheet (1-based); 0 defines it for the whole workbook.
+func (w *Writer) DefineNameRange(name string, r DataRange, scopeSheet int) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+	if err := r.validate(); err != nil {
+		return fmt.Errorf("defined name %q: %w", name, err)
+	}
+	if r.Sheet == 0 {
+		r.Sheet = w.current.sheetIndex + 1
+	}
+	return w.addDefinedName(definedName{name: name, scopeSheet: scopeSheet, dataRange: &r})
+}
+
+// SetPrintTitles repeats rows firstRow to lastRow (1-based) at the top of
+// every printed page of a sheet. sheet is 1-based and must already exist; 0
+// is the current sheet.
+func (w *Writer) SetPrintTitles(sheet, firstRow, lastRow int) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+	if firstRow < 1 || lastRow < firstRow || lastRow > w.config.MaxRowsPerSheet {
+		return fmt.Errorf("invalid print title rows %d to %d", firstRow, lastRow)
+	}
+	if sheet == 0 {
+		sheet = w.current.sheetIndex + 1
+	}
+	if sheet < 0 || sheet > len(w.sheetWriters) {
+		return fmt.Errorf("sheet %d does not exist", sheet)
+	}
+	return w.addDefinedName(definedName{
+		name:       printTitlesName,
+		scopeSheet: sheet,
+		refersTo:   fmt.Sprintf("%s!$%d:$%d", w.sheetReference(sheet), firstRow, lastRow),
+		builtin:    true,
+	})
+}
+
+// addDefinedName validates and records a defined name. Built-in names are
+// only checked for duplicates.
+func (w *Writer) addDefinedName(n definedName) error {
+	if !n.builtin {
+		if err := validateDefinedName(n.name); err != nil {
+			return err
+		}
+	}
+	if n.scopeSheet < 0 {
+		return fmt.Errorf("defined name %q has invalid scope sheet %d", n.name, n.scopeSheet)
+	}
+	for _, existing := range w.definedNames {
+		if existing.scopeSheet == n.scopeSheet && strings.EqualFold(existing.name, n.name) {
+			return fmt.Errorf("defined name %q already exists in this scope", n.name)
+		}
+	}
+
+	w.definedNames = append(w.definedNames, n)
+	return nil
+}
+
+// validateDefinedName checks a name against Excel's naming rules
+func validateDefinedName(name string) error {
+	if name == "" {
+		return fmt.Errorf("defined name must not be empty")
+	}
+	if utf8.RuneCountInString(name) > 255 {
+		return fmt.Errorf("defined name %q exceeds 255 characters", name)
+	}
+	if len(name) >= len(builtinNamePrefix) && strings.EqualFold(name[:len(builtinNamePrefix)], builtinNamePrefix) {
+		return fmt.Errorf("defined name %q uses the reserved %s prefix", name, builtinNamePrefix)
+	}
+	for i, r := range name {
+		valid := unicode.IsLetter(r) || r == '_' || r == '\\'
+		if i > 0 {
+			valid = valid || unicode.IsDigit(r) || r == '.'
+		}
+		if !valid {
+			return fmt.Errorf("defined name %q contains invalid character %q", name, r)
+		}
+	}
+	if _, _, err := parseCellReference(name); err == nil || r1c1Name.MatchString(name) {
+		return fmt.Errorf("defined name %q looks like a cell reference", name)
+	}
+	return nil
+}
+
+// generateDefinedNamesXML generates the <definedNames> block, resolving
+// ranges against the rows written to each sheet
+func (w *Writer) generateDefinedNamesXML() (string, error) {
+	if len(w.definedNames) == 0 {
+		return "", nil
+	}
+
+	var names strings.Builder
+	names.WriteString("<definedNames>\n")
+	for _, n := range w.definedNames {
+		refersTo := n.refersTo
+		if n.dataRange != nil {
+			var err error
+			if refersTo, err = w.resolveRange(*n.dataRange); err != nil {
+				return "", fmt.Errorf("defined name %q: %w", n.name, err)
+			}
+		}
+
+		localSheet := ""
+		if n.scopeSheet > 0 {
+			if n.scopeSheet > len(w.sheetWriters) {
+				return "", fmt.Errorf("defined name %q: sheet %d does not exist", n.name, n.scopeSheet)
+			}
+			localSheet = fmt.Sprintf(` localSheetId="%d"`, n.scopeSheet-1)
+		}
+		names.WriteString(fmt.Sprintf(`<definedName name="%s"%s>%s</definedName>
+`, escapeXML(n.name), localSheet, escapeXML(refersTo)))
+	}
+	names.WriteString("</definedNames>\n")
+	return names.String(), nil
+}
@@ -0,0 +1,117 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDefinedNames(t *testing.T) {
+	tmpFile := "test_defined_names.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 4
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"Region", "Sales", "Cost"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	if err := writer.DefineName("TaxRate", "=0.18", 0); err != nil {
+		t.Fatalf("Failed to define name: %v", err)
+	}
+	if err := writer.DefineNameRange("SalesData", DataRange{Column: "A:C"}, 0); err != nil {
+		t.Fatalf("Failed to define name: %v", err)
+	}
+	if err := writer.DefineName("Local", "Sheet1!$B$2", 1); err != nil {
+		t.Fatalf("Failed to define name: %v", err)
+	}
+	if err := writer.SetPrintTitles(0, 1, 1); err != nil {
+		t.Fatalf("Failed to set print titles: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := writer.WriteRow([]interface{}{"EMEA", i * 10, i}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+	if err := writer.DefineName("Local", "Sheet2!$B$1", 2); err != nil {
+		t.Fatalf("Failed to define name in another scope: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	workbook := readZipEntry(t, tmpFile, "xl/workbook.xml")
+	expected := `</sheets>
+<definedNames>
+<definedName name="TaxRate">0.18</definedName>
+<definedName name="SalesData">&#39;Sheet1&#39;!$A$2:$C$4</definedName>
+<definedName name="Local" localSheetId="0">Sheet1!$B$2</definedName>
+<definedName name="_xlnm.Print_Titles" localSheetId="0">&#39;Sheet1&#39;!$1:$1</definedName>
+<definedName name="Local" localSheetId="1">Sheet2!$B$1</definedName>
+</definedNames>
+</workbook>`
+	if !strings.Contains(workbook, expected) {
+		t.Errorf("Expected %s in workbook, got %s", expected, workbook)
+	}
+}
+
+func TestDefinedNameValidation(t *testing.T) {
+	tmpFile := "test_defined_names_invalid.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink, DefaultConfig())
+	if err := writer.DefineName("Early", "1", 0); err == nil {
+		t.Error("Expected error before StartFile")
+	}
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	for _, name := range []string{"", "A1", "xfd1048576", "R", "rc", "R1C1", "1st", "Has Space", "Total-2", strings.Repeat("n", 256), "_xlnm.Print_Area", "_XLNM.Custom"} {
+		if err := writer.DefineName(name, "1", 0); err == nil {
+			t.Errorf("Expected error for name %q", name)
+		}
+	}
+	for _, name := range []string{"_Total", "Sales.2024", `\Path`, "Ünite", "ABCD1"} {
+		if err := writer.DefineName(name, "1", 0); err != nil {
+			t.Errorf("Expected name %q to be valid: %v", name, err)
+		}
+	}
+
+	if err := writer.DefineName("_total", "2", 0); err == nil {
+		t.Error("Expected error for duplicate name ignoring case")
+	}
+	if err := writer.DefineName("Empty", " = ", 0); err == nil {
+		t.Error("Expected error for empty formula")
+	}
+	if err := writer.SetPrintTitles(1, 2, 1); err == nil {
+		t.Error("Expected error for invalid print title rows")
+	}
+	if err := writer.SetPrintTitles(9, 1, 1); err == nil {
+		t.Error("Expected error for print titles on a missing sheet")
+	}
+	if err := writer.DefineNameRange("Bad", DataRange{Column: "1"}, 0); err == nil {
+		t.Error("Expected error for invalid range column")
+	}
+
+	if err := writer.DefineName("Scoped", "1", 3); err != nil {
+		t.Fatalf("Failed to define name: %v", err)
+	}
+	if _, err := writer.FinishFile(); err == nil {
+		t.Error("Expected error for name scoped to a missing sheet")
+	}
+}
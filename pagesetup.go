@@ -0,0 +1,147 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// PageOrientation sets the printed page orientation
+type PageOrientation string
+
+const (
+	OrientationPortrait  PageOrientation = "portrait"
+	OrientationLandscape PageOrientation = "landscape"
+)
+
+// Common paper sizes for PageSetup.PaperSize
+const (
+	PaperLetter = 1
+	PaperLegal  = 5
+	PaperA3     = 8
+	PaperA4     = 9
+	PaperA5     = 11
+)
+
+// PageMargins sets the printed page margins in inches
+type PageMargins struct {
+	Left, Right float64
+	Top, Bottom float64
+	Header      float64
+	Footer      float64
+}
+
+// defaultPageMargins are Excel's "Normal" margins
+var defaultPageMargins = PageMargins{Left: 0.7, Right: 0.7, Top: 0.75, Bottom: 0.75, Header: 0.3, Footer: 0.3}
+
+// PageSetup configures how every sheet is printed
+type PageSetup struct {
+	Orientation PageOrientation
+	PaperSize   int // Excel paper size code such as PaperA4; 0 keeps the printer default
+
+	// FitToWidth and FitToHeight scale the sheet to fit that many pages across
+	// and down; 0 leaves that direction unconstrained
+	FitToWidth  int
+	FitToHeight int
+
+	Margins *PageMargins // nil uses Excel's normal margins
+
+	// HeaderText and FooterText use Excel's header codes, such as
+	// "&CPage &P of &N" for a centered page number
+	HeaderText string
+	FooterText string
+
+	PrintGridlines bool
+
+	// PrintArea limits printing to columns such as "A:F", covering every row
+	// written to each sheet
+	PrintArea string
+}
+
+// validate checks the page setup
+func (p *PageSetup) validate() error {
+	switch p.Orientation {
+	case "", OrientationPortrait, OrientationLandscape:
+	default:
+		return fmt.Errorf("invalid page orientation %q", p.Orientation)
+	}
+	if p.PaperSize < 0 || p.FitToWidth < 0 || p.FitToHeight < 0 {
+		return fmt.Errorf("paper size and fit to page counts must not be negative")
+	}
+	if m := p.Margins; m != nil {
+		if m.Left < 0 || m.Right < 0 || m.Top < 0 || m.Bottom < 0 || m.Header < 0 || m.Footer < 0 {
+			return fmt.Errorf("page margins must not be negative")
+		}
+	}
+	if utf8.RuneCountInString(p.HeaderText) > 255 || utf8.RuneCountInString(p.FooterText) > 255 {
+		return fmt.Errorf("header and footer text are limited to 255 characters")
+	}
+	if p.PrintArea != "" {
+		if _, _, err := parseColumnSpan(p.PrintArea); err != nil {
+			return fmt.Errorf("print area: %w", err)
+		}
+	}
+	return nil
+}
+
+// fitToPage reports whether the sheet is scaled to a number of pages
+func (p *PageSetup) fitToPage() bool {
+	return p.FitToWidth > 0 || p.FitToHeight > 0
+}
+
+// generateXML generates the <printOptions>, <pageMargins>, <pageSetup> and
+// <headerFooter> elements, in schema order
+func (p *PageSetup) generateXML() string {
+	var xml strings.Builder
+
+	if p.PrintGridlines {
+		xml.WriteString("<printOptions gridLines=\"1\"/>\n")
+	}
+
+	m := defaultPageMargins
+	if p.Margins != nil {
+		m = *p.Margins
+	}
+	xml.WriteString(fmt.Sprintf(`<pageMargins left="%s" right="%s" top="%s" bottom="%s" header="%s" footer="%s"/>
+`, formatFloat(m.Left, 64), formatFloat(m.Right, 64), formatFloat(m.Top, 64),
+		formatFloat(m.Bottom, 64), formatFloat(m.Header, 64), formatFloat(m.Footer, 64)))
+
+	var attrs strings.Builder
+	if p.PaperSize > 0 {
+		attrs.WriteString(fmt.Sprintf(` paperSize="%d"`, p.PaperSize))
+	}
+	if p.fitToPage() {
+		attrs.WriteString(fmt.Sprintf(` fitToWidth="%d" fitToHeight="%d"`, p.FitToWidth, p.FitToHeight))
+	}
+	if p.Orientation != "" {
+		attrs.WriteString(fmt.Sprintf(` orientation="%s"`, p.Orientation))
+	}
+	if attrs.Len() > 0 {
+		xml.WriteString(fmt.Sprintf("<pageSetup%s/>\n", attrs.String()))
+	}
+
+	if p.HeaderText != "" || p.FooterText != "" {
+		xml.WriteString("<headerFooter>")
+		if p.HeaderText != "" {
+			xml.WriteString(fmt.Sprintf("<oddHeader>%s</oddHeader>", escapeXML(p.HeaderText)))
+		}
+		if p.FooterText != "" {
+			xml.WriteString(fmt.Sprintf("<oddFooter>%s</oddFooter>", escapeXML(p.FooterText)))
+		}
+		xml.WriteString("</headerFooter>\n")
+	}
+
+	return xml.String()
+}
+
+// printArea returns the _xlnm.Print_Area name covering a sheet's rows
+func (w *Writer) printArea(sw *sheetWriter, columns string) definedName {
+	first, last, _ := parseColumnSpan(columns)
+	return definedName{
+		name:       printAreaName,
+		scopeSheet: sw.sheetIndex + 1,
+		refersTo: fmt.Sprintf("%s!$%s$1:$%s$%d", w.sheetReference(sw.sheetIndex+1),
+			columnName(first), columnName(last), max(sw.rowCount, 1)),
+		builtin: true,
+	}
+}
@@ -0,0 +1,102 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPageSetup(t *testing.T) {
+	tmpFile := "test_page_setup.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 4
+	config.SheetOptions.PageSetup = &PageSetup{
+		Orientation:    OrientationLandscape,
+		PaperSize:      PaperA4,
+		FitToWidth:     1,
+		Margins:        &PageMargins{Left: 0.25, Right: 0.25, Top: 0.5, Bottom: 0.5, Header: 0.2, Footer: 0.2},
+		HeaderText:     "&L&\"Arial,Bold\"Finance & Co",
+		FooterText:     "&CPage &P of &N",
+		PrintGridlines: true,
+		PrintArea:      "A:C",
+	}
+	config.SheetOptions.Validations = []DataValidation{{Columns: "B", Type: ValidationWhole, Formula1: 0, Formula2: 10}}
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID", "Qty", "Note"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	if err := writer.SetPrintTitles(1, 1, 1); err != nil {
+		t.Fatalf("Failed to set print titles: %v", err)
+	}
+	for i := 1; i <= 5; i++ {
+		if err := writer.WriteRow([]interface{}{i, i, "x"}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	if !strings.Contains(sheet1, `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheetPr><pageSetUpPr fitToPage="1"/></sheetPr>
+<sheetData>`) {
+		t.Errorf("Expected fit to page sheet properties, got %s", sheet1)
+	}
+
+	expected := `</dataValidations>
+<printOptions gridLines="1"/>
+<pageMargins left="0.25" right="0.25" top="0.5" bottom="0.5" header="0.2" footer="0.2"/>
+<pageSetup paperSize="9" fitToWidth="1" fitToHeight="0" orientation="landscape"/>
+<headerFooter><oddHeader>&amp;L&amp;&#34;Arial,Bold&#34;Finance &amp; Co</oddHeader><oddFooter>&amp;CPage &amp;P of &amp;N</oddFooter></headerFooter>
+</worksheet>`
+	if !strings.Contains(sheet1, expected) {
+		t.Errorf("Expected %s in sheet 1, got %s", expected, sheet1)
+	}
+
+	workbook := readZipEntry(t, tmpFile, "xl/workbook.xml")
+	for _, e := range []string{
+		`<definedName name="_xlnm.Print_Area" localSheetId="0">&#39;Sheet1&#39;!$A$1:$C$4</definedName>`,
+		`<definedName name="_xlnm.Print_Area" localSheetId="1">&#39;Sheet2&#39;!$A$1:$C$2</definedName>`,
+		`<definedName name="_xlnm.Print_Titles" localSheetId="0">`,
+	} {
+		if !strings.Contains(workbook, e) {
+			t.Errorf("Expected %s in workbook, got %s", e, workbook)
+		}
+	}
+}
+
+func TestPageSetupDefaults(t *testing.T) {
+	setup := &PageSetup{}
+	expected := "<pageMargins left=\"0.7\" right=\"0.7\" top=\"0.75\" bottom=\"0.75\" header=\"0.3\" footer=\"0.3\"/>\n"
+	if got := setup.generateXML(); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+	if got := (&SheetOptions{PageSetup: setup}).generateWorksheetHeader(); strings.Contains(got, "<sheetPr>") {
+		t.Errorf("Expected no sheet properties without fit to page, got %s", got)
+	}
+}
+
+func TestPageSetupValidation(t *testing.T) {
+	invalid := []PageSetup{
+		{Orientation: "sideways"},
+		{PaperSize: -1},
+		{FitToHeight: -2},
+		{Margins: &PageMargins{Left: -0.1}},
+		{FooterText: strings.Repeat("x", 256)},
+		{PrintArea: "A1:C"},
+	}
+	for i, setup := range invalid {
+		if err := setup.validate(); err == nil {
+			t.Errorf("Expected error for page setup %d", i)
+		}
+	}
+}
@@ -0,0 +1,323 @@
+package kolayxlsxstream
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const (
+	// defaultParallelBlockSize is the default uncompressed size of each block
+	// compressed on its own in pipelined mode
+	defaultParallelBlockSize = 1024 * 1024
+
+	// minParallelBlockSize keeps blocks large enough to compress well
+	minParallelBlockSize = 4096
+
+	// minRowsPerSegment is the smallest share of a WriteRows batch encoded on
+	// its own goroutine; smaller batches are encoded on the calling goroutine
+	minRowsPerSegment = 256
+
+	// maxRowsPerSegment bounds the encoded XML each goroutine holds before
+	// it is written
+	maxRowsPerSegment = 2048
+)
+
+// validateParallelConfig checks the pipelined mode settings
+func validateParallelConfig(config *Config) error {
+	if config.ParallelWorkers < 0 {
+		return fmt.Errorf("parallel workers must not be negative")
+	}
+	if config.ParallelBlockSize != 0 && config.ParallelBlockSize < minParallelBlockSize {
+		return fmt.Errorf("parallel block size must be at least %d bytes", minParallelBlockSize)
+	}
+	return nil
+}
+
+// compressorPool compresses the blocks of every part in pipelined mode,
+// running at most one block per worker at a time across all parts
+type compressorPool struct {
+	level     int
+	blockSize int
+	workers   int
+	slots     chan struct{} // One token per block being compressed
+	flaters   sync.Pool     // *flate.Writer, reused between blocks
+	buffers   sync.Pool     // *bytes.Buffer for uncompressed and compressed blocks
+}
+
+// newCompressorPool creates the block compressor for a pipelined Writer
+func newCompressorPool(config *Config) *compressorPool {
+	blockSize := config.ParallelBlockSize
+	if blockSize == 0 {
+		blockSize = defaultParallelBlockSize
+	}
+	return &compressorPool{
+		level:     config.CompressionLevel,
+		blockSize: blockSize,
+		workers:   config.ParallelWorkers,
+		slots:     make(chan struct{}, config.ParallelWorkers),
+	}
+}
+
+// newWriter returns a writer compressing a part into out as a sequence of
+// independently compressed blocks
+func (p *compressorPool) newWriter(out io.Writer) io.WriteCloser {
+	return &parallelFlateWriter{pool: p, out: out, block: p.buffer()}
+}
+
+// buffer returns an empty buffer from the pool
+func (p *compressorPool) buffer() *bytes.Buffer {
+	if b, ok := p.buffers.Get().(*bytes.Buffer); ok {
+		b.Reset()
+		return b
+	}
+	return bytes.NewBuffer(make([]byte, 0, p.blockSize))
+}
+
+// compress deflates a block into a new buffer. Every block but the last ends
+// with a sync flush, so the blocks form one valid deflate stream in order.
+func (p *compressorPool) compress(block *bytes.Buffer, final bool) (*bytes.Buffer, error) {
+	out := p.buffer()
+
+	fw, ok := p.flaters.Get().(*flate.Writer)
+	if ok {
+		fw.Reset(out)
+	} else {
+		var err error
+		if fw, err = flate.NewWriter(out, p.level); err != nil {
+			return nil, err
+		}
+	}
+	defer p.flaters.Put(fw)
+
+	if _, err := fw.Write(block.Bytes()); err != nil {
+		return nil, err
+	}
+	if final {
+		return out, fw.Close()
+	}
+	return out, fw.Flush()
+}
+
+// blockResult is a compressed block, or the error compressing it
+type blockResult struct {
+	data *bytes.Buffer
+	err  error
+}
+
+// parallelFlateWriter splits a part into blocks, compresses them on the pool
+// and writes them to out in order. At most two blocks per worker are in
+// flight for each part; Write blocks until the oldest is written when the
+// limit is reached.
+type parallelFlateWriter struct {
+	pool    *compressorPool
+	out     io.Writer
+	block   *bytes.Buffer      // Uncompressed block being filled
+	pending []chan blockResult // Blocks being compressed, in stream order
+	err     error
+}
+
+// Write buffers p, submitting each full block for compression
+func (pw *parallelFlateWriter) Write(p []byte) (int, error) {
+	if pw.err != nil {
+		return 0, pw.err
+	}
+
+	n := 0
+	for len(p) > 0 {
+		chunk := min(len(p), pw.pool.blockSize-pw.block.Len())
+		pw.block.Write(p[:chunk])
+		p = p[chunk:]
+		n += chunk
+
+		if pw.block.Len() == pw.pool.blockSize {
+			if err := pw.submit(false); err != nil {
+				return n, err
+			}
+		}
+	}
+	return n, nil
+}
+
+// submit starts compressing the current block and writes out the blocks
+// that are done, waiting for the oldest one when too many are in flight
+func (pw *parallelFlateWriter) submit(final bool) error {
+	block := pw.block
+	pw.block = pw.pool.buffer()
+
+	result := make(chan blockResult, 1)
+	pw.pending = append(pw.pending, result)
+
+	pw.pool.slots <- struct{}{}
+	go func() {
+		defer func() { <-pw.pool.slots }()
+		data, err := pw.pool.compress(block, final)
+		pw.pool.buffers.Put(block)
+		result <- blockResult{data: data, err: err}
+	}()
+
+	for len(pw.pending) > 0 {
+		if len(pw.pending) < 2*pw.pool.workers {
+			select {
+			case r := <-pw.pending[0]:
+				if err := pw.writeBlock(r); err != nil {
+					return err
+				}
+				continue
+			default:
+				return nil
+			}
+		}
+		if err := pw.writeBlock(<-pw.pending[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBlock writes the oldest pending block to out
+func (pw *parallelFlateWriter) writeBlock(r blockResult) error {
+	pw.pending = pw.pending[1:]
+	if r.err != nil {
+		pw.err = fmt.Errorf("failed to compress block: %w", r.err)
+		return pw.err
+	}
+	_, err := r.data.WriteTo(pw.out)
+	pw.pool.buffers.Put(r.data)
+	if err != nil {
+		pw.err = err
+	}
+	return err
+}
+
+// Close compresses the last block, which ends the deflate stream, and waits
+// until every block has been written
+func (pw *parallelFlateWriter) Close() error {
+	if pw.err != nil {
+		return pw.err
+	}
+	if err := pw.submit(true); err != nil {
+		return err
+	}
+	for len(pw.pending) > 0 {
+		if err := pw.writeBlock(<-pw.pending[0]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodedSegment is the XML of consecutive rows encoded by one goroutine,
+// ending at the first row that failed
+type encodedSegment struct {
+	xml  []byte
+	rows int
+	err  error
+}
+
+// writeRows writes rows to the sheet, encoding them on up to workers
+// goroutines when the batch is large enough. Segments of at most
+// maxRowsPerSegment rows are written in row order as they finish, so no more
+// than workers+1 segments are held in memory, up to the first row that
+// fails, exactly as one writeRow call per row would. It returns the number of
+// rows written.
+func (sw *sheetWriter) writeRows(rows [][]interface{}, workers int) (int, error) {
+	segments := min(workers, len(rows)/minRowsPerSegment)
+	if segments < 2 || hasHyperlinks(rows) {
+		// Hyperlinks are kept on the sheet while each row is encoded
+		for i, row := range rows {
+			if err := sw.writeRow(row, nil); err != nil {
+				return i, err
+			}
+		}
+		return len(rows), nil
+	}
+
+	// Column letters are only read while encoding concurrently
+	width := 0
+	for _, row := range rows {
+		width = max(width, len(row))
+	}
+	sw.growColumns(width)
+
+	first := sw.rowCount
+	size := min((len(rows)+segments-1)/segments, maxRowsPerSegment)
+	next := 0
+	var pending []chan encodedSegment // Segments being encoded, in row order
+	encodeNext := func() {
+		start, end := next, min(next+size, len(rows))
+		next = end
+		result := make(chan encodedSegment, 1)
+		pending = append(pending, result)
+		go func() {
+			result <- sw.encodeRows(first+start, rows[start:end])
+		}()
+	}
+	// wait lets the remaining goroutines finish reading rows before returning
+	wait := func() {
+		for _, result := range pending {
+			<-result
+		}
+	}
+
+	for next < len(rows) && len(pending) < workers {
+		encodeNext()
+	}
+	written := 0
+	for len(pending) > 0 {
+		seg := <-pending[0]
+		pending = pending[1:]
+		if seg.err == nil && next < len(rows) {
+			encodeNext()
+		}
+
+		if len(seg.xml) > 0 {
+			if _, err := sw.writer.Write(seg.xml); err != nil {
+				wait()
+				return written, fmt.Errorf("failed to write row: %w", err)
+			}
+			sw.rowCount += seg.rows
+			sw.lastRow = sw.rowCount
+			written += seg.rows
+		}
+		if seg.err != nil {
+			wait()
+			return written, fmt.Errorf("failed to generate row: %w", seg.err)
+		}
+	}
+	return written, nil
+}
+
+// encodeRows generates the XML of rows starting at a zero-based row index
+func (sw *sheetWriter) encodeRows(rowIndex int, rows [][]interface{}) encodedSegment {
+	var seg encodedSegment
+	for i, row := range rows {
+		rowXML, err := sw.appendRow(seg.xml, rowIndex+i, row, nil)
+		if err != nil {
+			seg.err = err
+			return seg
+		}
+		seg.xml = rowXML
+		seg.rows++
+	}
+	return seg
+}
+
+// hasHyperlinks reports whether any of the rows contains a hyperlink cell
+func hasHyperlinks(rows [][]interface{}) bool {
+	for _, row := range rows {
+		for _, value := range row {
+			switch v := value.(type) {
+			case Hyperlink:
+				return true
+			case Cell:
+				if _, ok := v.Value.(Hyperlink); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,175 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+// discardSink is a sink that drops everything written to it
+type discardSink struct{}
+
+func (discardSink) Write(p []byte) (int, error) { return len(p), nil }
+func (discardSink) Close() error                { return nil }
+
+// benchmarkRows returns rows with a mix of cell types
+func benchmarkRows(n int) [][]interface{} {
+	rows := make([][]interface{}, n)
+	for i := range rows {
+		rows[i] = []interface{}{i, fmt.Sprintf("Customer %d", i), float64(i) * 1.25, i%2 == 0, "Istanbul & Ankara"}
+	}
+	return rows
+}
+
+// writeParallelWorkload writes the same rows, in batches, with a config and
+// returns the file's worksheets
+func writeParallelWorkload(t *testing.T, path string, config *Config) []string {
+	t.Helper()
+
+	sink, err := NewFileSink(path)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	writer := NewWriter(sink, config)
+	if err := writer.StartFile([]interface{}{"ID", "Name", "Amount", "Active", "City"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	style, err := writer.AddStyle(Style{Font: &Font{Bold: true}})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+
+	named, err := writer.Sheet("Named")
+	if err != nil {
+		t.Fatalf("Failed to add sheet: %v", err)
+	}
+	if err := named.WriteRows(benchmarkRows(3000)); err != nil {
+		t.Fatalf("Failed to write named sheet rows: %v", err)
+	}
+
+	rows := benchmarkRows(50000)
+	for i := 0; i < len(rows); i += 100 {
+		rows[i][2] = Cell{Value: rows[i][2], StyleID: style}
+	}
+	// Row 20001 has an unknown style: the rows before it are written, as
+	// with WriteRow, and writing resumes after it
+	rows[20000][0] = Cell{Value: 1, StyleID: 99}
+
+	for start := 0; start < len(rows); start += 10000 {
+		err := writer.WriteRows(rows[start : start+10000])
+		if start == 20000 {
+			if err == nil || !strings.Contains(err.Error(), "unknown style ID 99") {
+				t.Fatalf("Expected unknown style error, got %v", err)
+			}
+			if err := writer.WriteRows(rows[start+1 : start+10000]); err != nil {
+				t.Fatalf("Failed to write rows: %v", err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("Failed to write rows: %v", err)
+		}
+	}
+
+	// Hyperlinks are encoded on the calling goroutine
+	links := [][]interface{}{}
+	for i := 0; i < 600; i++ {
+		links = append(links, []interface{}{Hyperlink{URL: fmt.Sprintf("https://example.com/%d", i)}})
+	}
+	if err := writer.WriteRows(links); err != nil {
+		t.Fatalf("Failed to write links: %v", err)
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 3000+49999+600 {
+		t.Errorf("Expected %d rows, got %d", 3000+49999+600, stats.TotalRows)
+	}
+
+	sheets := make([]string, stats.TotalSheets)
+	for i := range sheets {
+		sheets[i] = readZipEntry(t, path, fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+	}
+	sheets = append(sheets, readZipEntry(t, path, "xl/worksheets/_rels/sheet4.xml.rels"))
+	return sheets
+}
+
+func TestParallelMatchesSerial(t *testing.T) {
+	serialFile := "test_parallel_serial.xlsx"
+	parallelFile := "test_parallel.xlsx"
+	defer os.Remove(serialFile)
+	defer os.Remove(parallelFile)
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 22000
+	serial := writeParallelWorkload(t, serialFile, config)
+
+	config = DefaultConfig()
+	config.MaxRowsPerSheet = 22000
+	config.ParallelWorkers = 4
+	config.ParallelBlockSize = 64 * 1024
+	config.SpillThreshold = 64 * 1024
+	parallel := writeParallelWorkload(t, parallelFile, config)
+
+	if len(serial) != len(parallel) {
+		t.Fatalf("Expected %d parts, got %d", len(serial), len(parallel))
+	}
+	for i := range serial {
+		if serial[i] != parallel[i] {
+			t.Errorf("Part %d differs between serial and parallel output", i+1)
+		}
+	}
+	if !strings.Contains(parallel[0], `<row r="22000"><c r="A22000"><v>21999</v></c>`) {
+		t.Error("Expected the first main sheet to end at the row limit")
+	}
+}
+
+func TestParallelConfigValidation(t *testing.T) {
+	for _, config := range []*Config{
+		{ParallelWorkers: -1},
+		{ParallelWorkers: 4, ParallelBlockSize: 1024},
+	} {
+		cfg := DefaultConfig()
+		cfg.ParallelWorkers = config.ParallelWorkers
+		cfg.ParallelBlockSize = config.ParallelBlockSize
+
+		writer := NewWriter(discardSink{}, cfg)
+		if err := writer.StartFile(); err == nil {
+			t.Errorf("Expected error for workers %d and block size %d", config.ParallelWorkers, config.ParallelBlockSize)
+		}
+	}
+}
+
+// benchmarkWriteRows writes 100k rows in batches of 10k with a config
+func benchmarkWriteRows(b *testing.B, workers int) {
+	rows := benchmarkRows(100000)
+	b.ReportAllocs()
+
+	for b.Loop() {
+		config := DefaultConfig()
+		config.ParallelWorkers = workers
+		writer := NewWriter(discardSink{}, config)
+		if err := writer.StartFile(); err != nil {
+			b.Fatalf("Failed to start file: %v", err)
+		}
+		for start := 0; start < len(rows); start += 10000 {
+			if err := writer.WriteRows(rows[start : start+10000]); err != nil {
+				b.Fatalf("Failed to write rows: %v", err)
+			}
+		}
+		if _, err := writer.FinishFile(); err != nil {
+			b.Fatalf("Failed to finish file: %v", err)
+		}
+	}
+}
+
+func BenchmarkWriteRowsSerial(b *testing.B) {
+	benchmarkWriteRows(b, 0)
+}
+
+func BenchmarkWriteRowsParallel(b *testing.B) {
+	benchmarkWriteRows(b, 8)
+}
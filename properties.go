@@ -0,0 +1,190 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DocumentProperties holds the workbook's document properties, shown by
+// Excel under File > Info and by file managers
+type DocumentProperties struct {
+	Title       string
+	Subject     string
+	Creator     string
+	Keywords    string
+	Description string
+	Company     string
+
+	// Created defaults to the time StartFile is called; Modified defaults to Created
+	Created  time.Time
+	Modified time.Time
+
+	// Custom holds custom properties. Values may be strings, bools, integers,
+	// floats or time.Time.
+	Custom map[string]any
+}
+
+const (
+	relTypeCoreProperties     = "http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties"
+	relTypeExtendedProperties = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/extended-properties"
+	relTypeCustomProperties   = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties"
+
+	contentTypeCoreProperties     = "application/vnd.openxmlformats-package.core-properties+xml"
+	contentTypeExtendedProperties = "application/vnd.openxmlformats-officedocument.extended-properties+xml"
+	contentTypeCustomProperties   = "application/vnd.openxmlformats-officedocument.custom-properties+xml"
+
+	coreXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:dcmitype="http://purl.org/dc/dcmitype/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+`
+	coreXMLFooter = `</cp:coreProperties>`
+
+	appXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/extended-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">
+<Application>KolayXlsxStream</Application>
+<DocSecurity>0</DocSecurity>
+<ScaleCrop>false</ScaleCrop>
+`
+	appXMLFooter = `<LinksUpToDate>false</LinksUpToDate>
+<SharedDoc>false</SharedDoc>
+<HyperlinksChanged>false</HyperlinksChanged>
+</Properties>`
+
+	customXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Properties xmlns="http://schemas.openxmlformats.org/officeDocument/2006/custom-properties" xmlns:vt="http://schemas.openxmlformats.org/officeDocument/2006/docPropsVTypes">
+`
+	customXMLFooter = `</Properties>`
+
+	// customPropertyFmtID is the format ID Office uses for user-defined properties
+	customPropertyFmtID = "{D5CDD505-2E9C-101B-9397-08002B2CF9AE}"
+
+	// w3cdtfLayout is the date format of core properties
+	w3cdtfLayout = "2006-01-02T15:04:05Z"
+)
+
+// validate checks that the custom properties have names and supported values
+func (p *DocumentProperties) validate() error {
+	for name, value := range p.Custom {
+		if strings.TrimSpace(name) == "" {
+			return fmt.Errorf("custom property name must not be empty")
+		}
+		if len([]rune(name)) > 255 {
+			return fmt.Errorf("custom property name %q exceeds 255 characters", name)
+		}
+		if _, err := customPropertyValue(value); err != nil {
+			return fmt.Errorf("custom property %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// generateRelsXML generates the package relationships, pointing to the
+// workbook and the document properties parts
+func (p *DocumentProperties) generateRelsXML() string {
+	var rels strings.Builder
+	rels.WriteString(relsXMLHeader)
+	rels.WriteString(fmt.Sprintf(`<Relationship Id="rId2" Type="%s" Target="docProps/core.xml"/>
+<Relationship Id="rId3" Type="%s" Target="docProps/app.xml"/>
+`, relTypeCoreProperties, relTypeExtendedProperties))
+	if len(p.Custom) > 0 {
+		rels.WriteString(fmt.Sprintf(`<Relationship Id="rId4" Type="%s" Target="docProps/custom.xml"/>
+`, relTypeCustomProperties))
+	}
+	rels.WriteString(sheetRelsXMLFooter)
+	return rels.String()
+}
+
+// generateCoreXML generates docProps/core.xml
+func (p *DocumentProperties) generateCoreXML(started time.Time) string {
+	created := p.Created
+	if created.IsZero() {
+		created = started
+	}
+	modified := p.Modified
+	if modified.IsZero() {
+		modified = created
+	}
+
+	var core strings.Builder
+	core.WriteString(coreXMLHeader)
+	for _, element := range []struct{ name, value string }{
+		{"dc:title", p.Title},
+		{"dc:subject", p.Subject},
+		{"dc:creator", p.Creator},
+		{"cp:keywords", p.Keywords},
+		{"dc:description", p.Description},
+		{"cp:lastModifiedBy", p.Creator},
+	} {
+		if element.value != "" {
+			core.WriteString(fmt.Sprintf("<%s>%s</%s>\n", element.name, escapeXML(element.value), element.name))
+		}
+	}
+	core.WriteString(fmt.Sprintf(`<dcterms:created xsi:type="dcterms:W3CDTF">%s</dcterms:created>
+<dcterms:modified xsi:type="dcterms:W3CDTF">%s</dcterms:modified>
+`, created.UTC().Format(w3cdtfLayout), modified.UTC().Format(w3cdtfLayout)))
+	core.WriteString(coreXMLFooter)
+	return core.String()
+}
+
+// generateAppXML generates docProps/app.xml listing the sheet names
+func (p *DocumentProperties) generateAppXML(sheetNames []string) string {
+	var app strings.Builder
+	app.WriteString(appXMLHeader)
+	app.WriteString(fmt.Sprintf(`<HeadingPairs><vt:vector size="2" baseType="variant"><vt:variant><vt:lpstr>Worksheets</vt:lpstr></vt:variant><vt:variant><vt:i4>%d</vt:i4></vt:variant></vt:vector></HeadingPairs>
+`, len(sheetNames)))
+	app.WriteString(fmt.Sprintf(`<TitlesOfParts><vt:vector size="%d" baseType="lpstr">`, len(sheetNames)))
+	for _, name := range sheetNames {
+		app.WriteString(fmt.Sprintf("<vt:lpstr>%s</vt:lpstr>", escapeXML(name)))
+	}
+	app.WriteString("</vt:vector></TitlesOfParts>\n")
+	if p.Company != "" {
+		app.WriteString(fmt.Sprintf("<Company>%s</Company>\n", escapeXML(p.Company)))
+	}
+	app.WriteString(appXMLFooter)
+	return app.String()
+}
+
+// generateCustomXML generates docProps/custom.xml with properties sorted by name
+func (p *DocumentProperties) generateCustomXML() string {
+	var custom strings.Builder
+	custom.WriteString(customXMLHeader)
+	for i, name := range slices.Sorted(maps.Keys(p.Custom)) {
+		value, _ := customPropertyValue(p.Custom[name])
+		custom.WriteString(fmt.Sprintf(`<property fmtid="%s" pid="%d" name="%s">%s</property>
+`, customPropertyFmtID, i+2, escapeXML(name), value))
+	}
+	custom.WriteString(customXMLFooter)
+	return custom.String()
+}
+
+// customPropertyValue generates the typed value element of a custom property
+func customPropertyValue(value any) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return fmt.Sprintf("<vt:lpwstr>%s</vt:lpwstr>", escapeXML(v)), nil
+	case bool:
+		return fmt.Sprintf("<vt:bool>%t</vt:bool>", v), nil
+	case time.Time:
+		return fmt.Sprintf("<vt:filetime>%s</vt:filetime>", v.UTC().Format(w3cdtfLayout)), nil
+	case float32, float64:
+		f, bitSize, _ := toNumber(v)
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("value must be finite")
+		}
+		return fmt.Sprintf("<vt:r8>%s</vt:r8>", formatFloat(f, bitSize)), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		f, _, err := toNumber(v)
+		if err != nil {
+			return "", err
+		}
+		if f >= math.MinInt32 && f <= math.MaxInt32 {
+			return fmt.Sprintf("<vt:i4>%d</vt:i4>", int64(f)), nil
+		}
+		return fmt.Sprintf("<vt:r8>%s</vt:r8>", formatFloat(f, 64)), nil
+	default:
+		return "", fmt.Errorf("unsupported value type %T", value)
+	}
+}
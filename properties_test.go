@@ -0,0 +1,154 @@
+package kolayxlsxstream
+
+import (
+	"archive/zip"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDocumentProperties(t *testing.T) {
+	tmpFile := "test_properties.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 2
+	config.Properties = DocumentProperties{
+		Title:   "Q3 <Sales>",
+		Creator: "Reporting",
+		Company: "Acme & Co",
+		Created: time.Date(2024, 7, 1, 12, 30, 0, 0, time.FixedZone("UTC+3", 3*3600)),
+		Custom: map[string]any{
+			"Region":   "EMEA",
+			"Approved": true,
+			"Version":  3,
+			"Big":      int64(1) << 40,
+			"Ratio":    float32(0.25),
+			"Cutoff":   time.Date(2024, 6, 30, 0, 0, 0, 0, time.UTC),
+		},
+	}
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		if err := writer.WriteRow([]interface{}{i}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	rels := readZipEntry(t, tmpFile, "_rels/.rels")
+	for _, e := range []string{`Target="xl/workbook.xml"`, `Target="docProps/core.xml"`, `Target="docProps/app.xml"`, `Id="rId4" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/custom-properties" Target="docProps/custom.xml"`} {
+		if !strings.Contains(rels, e) {
+			t.Errorf("Expected %s in package rels, got %s", e, rels)
+		}
+	}
+
+	core := readZipEntry(t, tmpFile, "docProps/core.xml")
+	for _, e := range []string{
+		`<dc:title>Q3 &lt;Sales&gt;</dc:title>`,
+		`<dc:creator>Reporting</dc:creator>`,
+		`<dcterms:created xsi:type="dcterms:W3CDTF">2024-07-01T09:30:00Z</dcterms:created>`,
+		`<dcterms:modified xsi:type="dcterms:W3CDTF">2024-07-01T09:30:00Z</dcterms:modified>`,
+	} {
+		if !strings.Contains(core, e) {
+			t.Errorf("Expected %s in core properties, got %s", e, core)
+		}
+	}
+	if strings.Contains(core, "<dc:subject>") {
+		t.Error("Expected empty properties to be omitted")
+	}
+
+	app := readZipEntry(t, tmpFile, "docProps/app.xml")
+	for _, e := range []string{
+		`<vt:i4>2</vt:i4>`,
+		`<vt:vector size="2" baseType="lpstr"><vt:lpstr>Sheet1</vt:lpstr><vt:lpstr>Sheet2</vt:lpstr></vt:vector>`,
+		`<Application>KolayXlsxStream</Application>`,
+		`<Company>Acme &amp; Co</Company>`,
+	} {
+		if !strings.Contains(app, e) {
+			t.Errorf("Expected %s in app properties, got %s", e, app)
+		}
+	}
+	if strings.Contains(app, "<AppVersion>") {
+		t.Error("Expected no application version")
+	}
+
+	custom := readZipEntry(t, tmpFile, "docProps/custom.xml")
+	for _, e := range []string{
+		`pid="2" name="Approved"><vt:bool>true</vt:bool>`,
+		`name="Big"><vt:r8>1099511627776</vt:r8>`,
+		`name="Cutoff"><vt:filetime>2024-06-30T00:00:00Z</vt:filetime>`,
+		`name="Ratio"><vt:r8>0.25</vt:r8>`,
+		`name="Region"><vt:lpwstr>EMEA</vt:lpwstr>`,
+		`pid="7" name="Version"><vt:i4>3</vt:i4>`,
+	} {
+		if !strings.Contains(custom, e) {
+			t.Errorf("Expected %s in custom properties, got %s", e, custom)
+		}
+	}
+
+	contentTypes := readZipEntry(t, tmpFile, "[Content_Types].xml")
+	if !strings.Contains(contentTypes, `<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>`) {
+		t.Errorf("Expected core properties content type, got %s", contentTypes)
+	}
+}
+
+func TestDocumentPropertiesDefaults(t *testing.T) {
+	tmpFile := "test_properties_defaults.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink, DefaultConfig())
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	core := readZipEntry(t, tmpFile, "docProps/core.xml")
+	if !strings.Contains(core, `<dcterms:created xsi:type="dcterms:W3CDTF">`+time.Now().UTC().Format("2006-01-02")) {
+		t.Errorf("Expected created date to default to now, got %s", core)
+	}
+
+	r, err := zip.OpenReader(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if f.Name == "docProps/custom.xml" {
+			t.Error("Expected no custom properties part")
+		}
+	}
+}
+
+func TestDocumentPropertiesValidation(t *testing.T) {
+	for _, custom := range []map[string]any{
+		{"": "x"},
+		{"Bad": []int{1}},
+		{"NaN": math.NaN()},
+		{"Huge": uint64(1<<53 + 1)},
+	} {
+		props := DocumentProperties{Custom: custom}
+		if err := props.validate(); err == nil {
+			t.Errorf("Expected error for custom properties %v", custom)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package kolayxlsxstream
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+)
+
+// SheetProtection locks every sheet against editing. Cells stay editable when
+// their style sets CellProtection.Locked to false. The Allow fields permit
+// actions that protection blocks by default.
+type SheetProtection struct {
+	Password string // Optional; an empty password lets anyone unprotect the sheet
+
+	AllowFormatCells      bool
+	AllowFormatColumns    bool
+	AllowFormatRows       bool
+	AllowInsertColumns    bool
+	AllowInsertRows       bool
+	AllowInsertHyperlinks bool
+	AllowDeleteColumns    bool
+	AllowDeleteRows       bool
+	AllowSort             bool
+	AllowFilter           bool
+	AllowPivotTables      bool
+
+	// DenySelectLockedCells and DenySelectUnlockedCells block selecting cells,
+	// which protection allows by default
+	DenySelectLockedCells   bool
+	DenySelectUnlockedCells bool
+}
+
+// WorkbookProtection locks the workbook's structure (adding, removing,
+// renaming or moving sheets) and window arrangement
+type WorkbookProtection struct {
+	Password      string
+	LockStructure bool
+	LockWindows   bool
+}
+
+// CellProtection sets a cell's protection, which takes effect when the sheet
+// is protected. Cells without a CellProtection are locked and visible.
+type CellProtection struct {
+	Locked bool
+	Hidden bool // Hides the cell's formula in the formula bar
+}
+
+// protectionSpinCount is the number of hash iterations Excel uses
+const protectionSpinCount = 100000
+
+// passwordHash is a password hashed with Excel's SHA-512 scheme
+type passwordHash struct {
+	hash, salt string
+}
+
+// hashPassword hashes a password with a random salt: the salted UTF-16LE
+// password is hashed once, then the hash is rehashed with each iteration
+// number appended as a little-endian uint32
+func hashPassword(password string) (passwordHash, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return passwordHash{}, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return passwordHash{
+		hash: base64.StdEncoding.EncodeToString(hashPasswordWithSalt(password, salt, protectionSpinCount)),
+		salt: base64.StdEncoding.EncodeToString(salt),
+	}, nil
+}
+
+// hashPasswordWithSalt computes the SHA-512 password hash
+func hashPasswordWithSalt(password string, salt []byte, spinCount int) []byte {
+	data := append([]byte(nil), salt...)
+	for _, u := range utf16.Encode([]rune(password)) {
+		data = binary.LittleEndian.AppendUint16(data, u)
+	}
+	sum := sha512.Sum512(data)
+	hash := sum[:]
+
+	buf := make([]byte, sha512.Size+4)
+	for i := 0; i < spinCount; i++ {
+		copy(buf, hash)
+		binary.LittleEndian.PutUint32(buf[sha512.Size:], uint32(i))
+		sum = sha512.Sum512(buf)
+		hash = sum[:]
+	}
+	return hash
+}
+
+// generateXML generates the <sheetProtection> element
+func (p *SheetProtection) generateXML(hash *passwordHash) string {
+	var xml strings.Builder
+	xml.WriteString("<sheetProtection")
+	if hash != nil {
+		xml.WriteString(fmt.Sprintf(` algorithmName="SHA-512" hashValue="%s" saltValue="%s" spinCount="%d"`, hash.hash, hash.salt, protectionSpinCount))
+	}
+	xml.WriteString(` sheet="1" objects="1" scenarios="1"`)
+
+	// Actions are blocked unless allowed
+	for _, attr := range []struct {
+		name    string
+		allowed bool
+	}{
+		{"formatCells", p.AllowFormatCells},
+		{"formatColumns", p.AllowFormatColumns},
+		{"formatRows", p.AllowFormatRows},
+		{"insertColumns", p.AllowInsertColumns},
+		{"insertRows", p.AllowInsertRows},
+		{"insertHyperlinks", p.AllowInsertHyperlinks},
+		{"deleteColumns", p.AllowDeleteColumns},
+		{"deleteRows", p.AllowDeleteRows},
+		{"sort", p.AllowSort},
+		{"autoFilter", p.AllowFilter},
+		{"pivotTables", p.AllowPivotTables},
+	} {
+		if attr.allowed {
+			xml.WriteString(fmt.Sprintf(` %s="0"`, attr.name))
+		}
+	}
+
+	// Selecting cells is allowed unless denied
+	if p.DenySelectLockedCells {
+		xml.WriteString(` selectLockedCells="1"`)
+	}
+	if p.DenySelectUnlockedCells {
+		xml.WriteString(` selectUnlockedCells="1"`)
+	}
+	xml.WriteString("/>\n")
+	return xml.String()
+}
+
+// generateXML generates the <workbookProtection> element
+func (p *WorkbookProtection) generateXML(hash *passwordHash) string {
+	var xml strings.Builder
+	xml.WriteString("<workbookProtection")
+	if hash != nil {
+		xml.WriteString(fmt.Sprintf(` workbookAlgorithmName="SHA-512" workbookHashValue="%s" workbookSaltValue="%s" workbookSpinCount="%d"`, hash.hash, hash.salt, protectionSpinCount))
+	}
+	xml.WriteString(` lockStructure="` + boolAttr(p.LockStructure) + `"`)
+	xml.WriteString(` lockWindows="` + boolAttr(p.LockWindows) + `"`)
+	xml.WriteString("/>\n")
+	return xml.String()
+}
+
+// generateProtectionXML generates a cell format's <protection> element
+func generateProtectionXML(p *CellProtection) string {
+	return fmt.Sprintf(`<protection locked="%s" hidden="%s"/>`, boolAttr(p.Locked), boolAttr(p.Hidden))
+}
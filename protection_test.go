@@ -0,0 +1,100 @@
+package kolayxlsxstream
+
+import (
+	"encoding/base64"
+	"os"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestHashPassword(t *testing.T) {
+	salt := make([]byte, 16)
+	for i := range salt {
+		salt[i] = byte(i)
+	}
+
+	// Reference value computed independently with Python's hashlib
+	expected := "sVrNjpFUgoKVzikhO5SMuokull5k511RxXD4WOAOqi2dR4FdAPBTEzx7cF3o20+dtnijmL+M7H4teaUBHj7Xzw=="
+	got := base64.StdEncoding.EncodeToString(hashPasswordWithSalt("Sécret", salt, protectionSpinCount))
+	if got != expected {
+		t.Errorf("Expected hash %s, got %s", expected, got)
+	}
+
+	first, err := hashPassword("secret")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	second, err := hashPassword("secret")
+	if err != nil {
+		t.Fatalf("Failed to hash password: %v", err)
+	}
+	if first.salt == second.salt || first.hash == second.hash {
+		t.Error("Expected a random salt for each hash")
+	}
+}
+
+func TestProtection(t *testing.T) {
+	tmpFile := "test_protection.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.SheetOptions.Protection = &SheetProtection{
+		Password:              "partner",
+		AllowSort:             true,
+		AllowFilter:           true,
+		AllowFormatColumns:    true,
+		DenySelectLockedCells: true,
+	}
+	config.SheetOptions.Validations = []DataValidation{{Columns: "B", Type: ValidationWhole, Formula1: 0, Formula2: 10}}
+	config.WorkbookProtection = &WorkbookProtection{Password: "structure", LockStructure: true}
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID", "Qty"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	unlocked, err := writer.AddStyle(Style{Protection: &CellProtection{Locked: false}})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+	if err := writer.WriteRow([]interface{}{1, Cell{Value: 5, StyleID: unlocked}}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	sheetProtection := regexp.MustCompile(`</sheetData>
+<sheetProtection algorithmName="SHA-512" hashValue="[A-Za-z0-9+/]{86}==" saltValue="[A-Za-z0-9+/]{22}==" spinCount="100000" sheet="1" objects="1" scenarios="1" formatColumns="0" sort="0" autoFilter="0" selectLockedCells="1"/>
+<dataValidations`)
+	if !sheetProtection.MatchString(sheet) {
+		t.Errorf("Expected sheet protection after sheet data, got %s", sheet)
+	}
+
+	workbook := readZipEntry(t, tmpFile, "xl/workbook.xml")
+	workbookProtection := regexp.MustCompile(`<workbookProtection workbookAlgorithmName="SHA-512" workbookHashValue="[^"]+" workbookSaltValue="[^"]+" workbookSpinCount="100000" lockStructure="1" lockWindows="0"/>
+<sheets>`)
+	if !workbookProtection.MatchString(workbook) {
+		t.Errorf("Expected workbook protection before sheets, got %s", workbook)
+	}
+
+	styles := readZipEntry(t, tmpFile, "xl/styles.xml")
+	if !strings.Contains(styles, `applyProtection="1"><protection locked="0" hidden="0"/></xf>`) {
+		t.Errorf("Expected unlocked cell style, got %s", styles)
+	}
+}
+
+func TestProtectionWithoutPassword(t *testing.T) {
+	p := &SheetProtection{}
+	expected := "<sheetProtection sheet=\"1\" objects=\"1\" scenarios=\"1\"/>\n"
+	if got := p.generateXML(nil); got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+}
@@ -0,0 +1,108 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Font describes the font properties of a rich text run
+type Font struct {
+	Name      string  // Font name, e.g. "Calibri"
+	Size      float64 // Size in points
+	Bold      bool
+	Italic    bool
+	Underline bool
+	Strike    bool
+	Color     string // RGB or ARGB hex color, e.g. "FF0000" or "FFFF0000"
+}
+
+// RichTextRun is a piece of text sharing the same font properties.
+// A nil Font uses the cell's font.
+type RichTextRun struct {
+	Text string
+	Font *Font
+}
+
+// RichText is a cell value made of runs with individual font properties,
+// e.g. to highlight the changed part of a value
+type RichText []RichTextRun
+
+// appendRichTextCell appends the XML for a rich text cell
+func appendRichTextCell(dst []byte, ref cellRef, style int, rt RichText) ([]byte, error) {
+	dst = appendCellStart(dst, ref, style, "inlineStr")
+	dst = append(dst, `<is>`...)
+
+	if len(rt) == 0 {
+		dst = append(dst, `<t></t>`...)
+	}
+	for _, run := range rt {
+		dst = append(dst, `<r>`...)
+		if run.Font != nil {
+			rPr, err := generateRunProperties(run.Font)
+			if err != nil {
+				return dst, fmt.Errorf("cell %s: %w", ref, err)
+			}
+			dst = append(dst, rPr...)
+		}
+		dst = appendText(dst, run.Text)
+		dst = append(dst, `</r>`...)
+	}
+
+	return append(dst, `</is></c>`...), nil
+}
+
+// generateRunProperties generates the <rPr> element for a rich text run
+func generateRunProperties(f *Font) (string, error) {
+	var rPr strings.Builder
+	rPr.WriteString(`<rPr>`)
+	if f.Name != "" {
+		rPr.WriteString(fmt.Sprintf(`<rFont val="%s"/>`, escapeXML(f.Name)))
+	}
+	if f.Bold {
+		rPr.WriteString(`<b/>`)
+	}
+	if f.Italic {
+		rPr.WriteString(`<i/>`)
+	}
+	if f.Strike {
+		rPr.WriteString(`<strike/>`)
+	}
+	if f.Color != "" {
+		color, err := normalizeColor(f.Color)
+		if err != nil {
+			return "", err
+		}
+		rPr.WriteString(fmt.Sprintf(`<color rgb="%s"/>`, color))
+	}
+	if f.Size > 0 {
+		rPr.WriteString(fmt.Sprintf(`<sz val="%s"/>`, strconv.FormatFloat(f.Size, 'f', -1, 64)))
+	}
+	if f.Underline {
+		rPr.WriteString(`<u/>`)
+	}
+	rPr.WriteString(`</rPr>`)
+	return rPr.String(), nil
+}
+
+// normalizeColor validates an RGB or ARGB hex color and returns it as
+// upper-case ARGB
+func normalizeColor(color string) (string, error) {
+	c := strings.TrimPrefix(color, "#")
+	if len(c) == 6 {
+		c = "FF" + c
+	}
+	if len(c) != 8 {
+		return "", fmt.Errorf("invalid color %q: expected RGB or ARGB hex", color)
+	}
+	if _, err := strconv.ParseUint(c, 16, 32); err != nil {
+		return "", fmt.Errorf("invalid color %q: expected RGB or ARGB hex", color)
+	}
+	return strings.ToUpper(c), nil
+}
+
+// textElement generates a <t> element, preserving leading and trailing
+// whitespace that Excel would otherwise trim
+func textElement(s string) string {
+	return string(appendText(nil, s))
+}
@@ -0,0 +1,89 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRichTextCell(t *testing.T) {
+	rt := RichText{
+		{Text: "Amount changed from "},
+		{Text: "100", Font: &Font{Bold: true, Strike: true}},
+		{Text: " to "},
+		{Text: "120", Font: &Font{Name: "Arial", Size: 10.5, Bold: true, Italic: true, Underline: true, Color: "ff0000"}},
+	}
+
+	got, err := cellXML(newTestSheetWriter(nil), "C4", rt)
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := `<c r="C4" t="inlineStr"><is>` +
+		`<r><t xml:space="preserve">Amount changed from </t></r>` +
+		`<r><rPr><b/><strike/></rPr><t>100</t></r>` +
+		`<r><t xml:space="preserve"> to </t></r>` +
+		`<r><rPr><rFont val="Arial"/><b/><i/><color rgb="FFFF0000"/><sz val="10.5"/><u/></rPr><t>120</t></r>` +
+		`</is></c>`
+	if got != expected {
+		t.Errorf("Expected %s, got %s", expected, got)
+	}
+
+	if _, err := cellXML(newTestSheetWriter(nil), "A1", RichText{{Text: "x", Font: &Font{Color: "red"}}}); err == nil {
+		t.Error("Expected error for invalid color")
+	}
+}
+
+func TestStringWhitespacePreserved(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected string
+	}{
+		{"plain", `<t>plain</t>`},
+		{"inner  spaces", `<t>inner  spaces</t>`},
+		{"  indented", `<t xml:space="preserve">  indented</t>`},
+		{"trailing ", `<t xml:space="preserve">trailing </t>`},
+		{"line\n", `<t xml:space="preserve">line&#xA;</t>`},
+	}
+
+	for _, tt := range tests {
+		got, err := cellXML(newTestSheetWriter(nil), "A1", tt.value)
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if !strings.Contains(got, tt.expected) {
+			t.Errorf("Expected %s for %q, got %s", tt.expected, tt.value, got)
+		}
+	}
+}
+
+func TestWriteRichText(t *testing.T) {
+	tmpFile := "test_richtext.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink)
+
+	if err := writer.StartFile([]interface{}{"Audit"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	row := []interface{}{RichText{{Text: "Status: "}, {Text: "FAILED", Font: &Font{Bold: true, Color: "C00000"}}}}
+	if err := writer.WriteRow(row); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheetXML := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := `<r><rPr><b/><color rgb="FFC00000"/></rPr><t>FAILED</t></r>`
+	if !strings.Contains(sheetXML, expected) {
+		t.Errorf("Expected %s in sheet XML, got %s", expected, sheetXML)
+	}
+}
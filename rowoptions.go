@@ -0,0 +1,88 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+const (
+	// maxOutlineLevel is the deepest row grouping Excel supports
+	maxOutlineLevel = 7
+
+	// maxRowHeight is the tallest row height in points Excel supports
+	maxRowHeight = 409
+)
+
+// RowOptions sets attributes of a single row
+type RowOptions struct {
+	// OutlineLevel groups the row (1-7); 0 leaves it ungrouped. Levels above
+	// 0 must not exceed SheetOptions.MaxOutlineLevel.
+	OutlineLevel int
+
+	// Collapsed marks the row whose outline button collapses the group next to it
+	Collapsed bool
+
+	Hidden bool
+
+	// Height sets the row height in points (up to 409); 0 keeps the default
+	Height float64
+
+	// StyleID applies a style from AddStyle to the row: to the cells left
+	// out of it, and to the written cells, empty or not, that have no style
+	// of their own. Cells with their own style keep it.
+	StyleID int
+}
+
+// WriteRowWithOptions writes a row with outline level, visibility, height or
+// style attributes. Rows written with WriteRow have no options.
+func (w *Writer) WriteRowWithOptions(values []interface{}, opts RowOptions) error {
+	if err := opts.validate(w.config.SheetOptions.MaxOutlineLevel, w.styles.cellStyleCount()); err != nil {
+		return err
+	}
+	return w.writeRow(values, &opts)
+}
+
+// validate checks the options against the sheets' declared outline depth and
+// the registered styles
+func (o *RowOptions) validate(maxLevel, styleCount int) error {
+	if o.OutlineLevel < 0 || o.OutlineLevel > maxOutlineLevel {
+		return fmt.Errorf("outline level must be between 0 and %d, got %d", maxOutlineLevel, o.OutlineLevel)
+	}
+	if o.OutlineLevel > maxLevel {
+		return fmt.Errorf("outline level %d exceeds SheetOptions.MaxOutlineLevel (%d)", o.OutlineLevel, maxLevel)
+	}
+	if o.Height < 0 || o.Height > maxRowHeight || math.IsNaN(o.Height) {
+		return fmt.Errorf("row height must be between 0 and %d points, got %v", maxRowHeight, o.Height)
+	}
+	if o.StyleID < 0 || o.StyleID >= styleCount {
+		return fmt.Errorf("invalid row style ID %d", o.StyleID)
+	}
+	return nil
+}
+
+// appendAttributes appends the <row> attributes for the options
+func (o *RowOptions) appendAttributes(dst []byte) []byte {
+	if o.StyleID > 0 {
+		dst = append(dst, ` s="`...)
+		dst = strconv.AppendInt(dst, int64(o.StyleID), 10)
+		dst = append(dst, `" customFormat="1"`...)
+	}
+	if o.Height > 0 {
+		dst = append(dst, ` ht="`...)
+		dst = appendFloat(dst, o.Height, 64)
+		dst = append(dst, `" customHeight="1"`...)
+	}
+	if o.Hidden {
+		dst = append(dst, ` hidden="1"`...)
+	}
+	if o.OutlineLevel > 0 {
+		dst = append(dst, ` outlineLevel="`...)
+		dst = strconv.AppendInt(dst, int64(o.OutlineLevel), 10)
+		dst = append(dst, '"')
+	}
+	if o.Collapsed {
+		dst = append(dst, ` collapsed="1"`...)
+	}
+	return dst
+}
@@ -0,0 +1,163 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestRowOutline(t *testing.T) {
+	tmpFile := "test_row_outline.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.SheetOptions.MaxOutlineLevel = 2
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"Name", "Amount"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	rows := []struct {
+		values []interface{}
+		opts   RowOptions
+	}{
+		{[]interface{}{"Invoice 1", 10}, RowOptions{OutlineLevel: 2, Hidden: true}},
+		{[]interface{}{"Invoice 2", 20}, RowOptions{OutlineLevel: 2, Hidden: true}},
+		{[]interface{}{"Account A", 30}, RowOptions{OutlineLevel: 1, Collapsed: true}},
+		{[]interface{}{"EMEA", 30}, RowOptions{}},
+	}
+	for _, row := range rows {
+		if err := writer.WriteRowWithOptions(row.values, row.opts); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+	}
+
+	if err := writer.WriteRowWithOptions([]interface{}{"Too deep"}, RowOptions{OutlineLevel: 3}); err == nil {
+		t.Error("Expected error for outline level above the declared maximum")
+	}
+	if err := writer.WriteRowWithOptions([]interface{}{"Negative"}, RowOptions{OutlineLevel: -1}); err == nil {
+		t.Error("Expected error for negative outline level")
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 4 {
+		t.Errorf("Expected 4 rows, got %d", stats.TotalRows)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := []string{
+		`<sheetPr><outlinePr summaryBelow="1"/></sheetPr>
+<sheetFormatPr defaultRowHeight="15" outlineLevelRow="2"/>
+<sheetData>`,
+		`<row r="1"><c r="A1"`,
+		`<row r="2" hidden="1" outlineLevel="2"><c r="A2"`,
+		`<row r="4" outlineLevel="1" collapsed="1"><c r="A4"`,
+		`<row r="5"><c r="A5"`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+}
+
+func TestRowOutlineValidation(t *testing.T) {
+	for _, level := range []int{-1, 8} {
+		opts := SheetOptions{MaxOutlineLevel: level}
+		if err := opts.validate(); err == nil {
+			t.Errorf("Expected error for max outline level %d", level)
+		}
+	}
+
+	opts := SheetOptions{MaxOutlineLevel: 1, OutlineSummaryAbove: true}
+	if got := opts.generateWorksheetHeader(); !strings.Contains(got, `<sheetPr><outlinePr summaryBelow="0"/></sheetPr>`) {
+		t.Errorf("Expected summary rows above details, got %s", got)
+	}
+
+	// Rows without grouping need no declared maximum
+	row := RowOptions{Hidden: true}
+	if err := row.validate(0, 1); err != nil {
+		t.Errorf("Expected hidden row to be valid: %v", err)
+	}
+	row = RowOptions{OutlineLevel: 1}
+	if err := row.validate(0, 1); err == nil {
+		t.Error("Expected error for outline level without a declared maximum")
+	}
+}
+
+func TestRowHeightAndStyle(t *testing.T) {
+	tmpFile := "test_row_style.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink, DefaultConfig())
+	if err := writer.StartFile([]interface{}{"Section", "Value"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	separator, err := writer.AddStyle(Style{Fill: &Fill{Color: "D9D9D9"}})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+
+	if err := writer.WriteRowWithOptions(nil, RowOptions{Height: 4.5, StyleID: separator}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+	if err := writer.WriteRowWithOptions([]interface{}{"Totals", 42}, RowOptions{Height: 30}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+	if err := writer.WriteRowWithOptions([]interface{}{"Hidden"}, RowOptions{Hidden: true}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+
+	// Unstyled and empty cells take the row style; styled cells keep theirs
+	bold, err := writer.AddStyle(Style{Font: &Font{Bold: true}})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+	styled := []interface{}{"Section", nil, Cell{Value: 1, StyleID: bold}, Cell{Value: 2}}
+	if err := writer.WriteRowWithOptions(styled, RowOptions{StyleID: separator}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+
+	for _, opts := range []RowOptions{{Height: -1}, {Height: 410}, {StyleID: 99}, {StyleID: -1}} {
+		if err := writer.WriteRowWithOptions([]interface{}{"x"}, opts); err == nil {
+			t.Errorf("Expected error for row options %+v", opts)
+		}
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := []string{
+		fmt.Sprintf(`<row r="2" s="%d" customFormat="1" ht="4.5" customHeight="1"></row>`, separator),
+		`<row r="3" ht="30" customHeight="1"><c r="A3"`,
+		`<row r="4" hidden="1"><c r="A4"`,
+		fmt.Sprintf(`<row r="5" s="%[1]d" customFormat="1"><c r="A5" s="%[1]d" t="inlineStr"><is><t>Section</t></is></c><c r="B5" s="%[1]d"/>`+
+			`<c r="C5" s="%[2]d"><v>1</v></c><c r="D5" s="%[1]d"><v>2</v></c></row>`, separator, bold),
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected %s in sheet, got %s", e, sheet)
+		}
+	}
+	if strings.Contains(sheet, `<row r="6"`) {
+		t.Error("Expected invalid rows not to be written")
+	}
+}
@@ -0,0 +1,221 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// SheetHandle writes rows to a named sheet. Each handle spools its sheet
+// separately (compressed, in memory up to Config.SpillThreshold and then in a
+// temp file), so different handles, and the Writer's own row methods, can be
+// used from different goroutines at the same time. A single handle must not
+// be used concurrently.
+type SheetHandle struct {
+	writer *Writer
+	sw     *sheetWriter
+	rows   int
+}
+
+// Sheet adds a named sheet and returns a handle for writing its rows,
+// optionally starting with a header row. Call Sheet from the goroutine that
+// drives the Writer; the handle can then be passed to another goroutine.
+// Named sheets use Config.SheetOptions except Table, and do not roll over:
+// writing more than MaxRowsPerSheet rows is an error.
+func (w *Writer) Sheet(name string, headers ...[]interface{}) (*SheetHandle, error) {
+	if err := w.checkWritable(); err != nil {
+		return nil, err
+	}
+	if err := w.validateSheetName(name); err != nil {
+		return nil, err
+	}
+
+	spool, err := newSheetSpool(w.config, w.newCompressor)
+	if err != nil {
+		return nil, err
+	}
+	sw := &sheetWriter{
+		writer:     spool,
+		config:     w.config,
+		styles:     w.styles,
+		sheetIndex: len(w.sheetWriters),
+		name:       name,
+		spool:      spool,
+	}
+
+	if _, err := io.WriteString(sw.writer, w.config.SheetOptions.generateWorksheetHeader()); err != nil {
+		sw.release()
+		return nil, fmt.Errorf("failed to write worksheet header: %w", err)
+	}
+	if len(headers) > 0 && len(headers[0]) > 0 {
+		if err := sw.writeRow(headers[0], nil); err != nil {
+			sw.release()
+			return nil, fmt.Errorf("failed to write headers: %w", err)
+		}
+		sw.headersDone = true
+	}
+
+	w.sheetWriters = append(w.sheetWriters, sw)
+	h := &SheetHandle{writer: w, sw: sw}
+	w.handles = append(w.handles, h)
+	return h, nil
+}
+
+// validateSheetName checks a sheet name against Excel's rules and the names
+// already in use, including those reserved for rollover sheets
+func (w *Writer) validateSheetName(name string) error {
+	if name == "" || utf8.RuneCountInString(name) > 31 {
+		return fmt.Errorf("sheet name %q must be 1 to 31 characters", name)
+	}
+	if strings.ContainsAny(name, `\/?*[]:`) {
+		return fmt.Errorf("sheet name %q must not contain any of \\ / ? * [ ] :", name)
+	}
+	if strings.HasPrefix(name, "'") || strings.HasSuffix(name, "'") {
+		return fmt.Errorf("sheet name %q must not start or end with an apostrophe", name)
+	}
+	if strings.EqualFold(name, "History") {
+		return fmt.Errorf("sheet name %q is reserved by Excel", name)
+	}
+
+	prefix := w.config.SheetNamePrefix
+	if len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+		if _, err := strconv.Atoi(name[len(prefix):]); err == nil {
+			return fmt.Errorf("sheet name %q is reserved for rollover sheets", name)
+		}
+	}
+	for _, sw := range w.sheetWriters {
+		if strings.EqualFold(sw.name, name) {
+			return fmt.Errorf("sheet name %q is already in use", name)
+		}
+	}
+	return nil
+}
+
+// Name returns the sheet's name
+func (h *SheetHandle) Name() string {
+	return h.sw.name
+}
+
+// Number returns the sheet's 1-based position in the workbook, as used by
+// DataRange.Sheet and the scope of defined names
+func (h *SheetHandle) Number() int {
+	return h.sw.sheetIndex + 1
+}
+
+// WriteRow writes a single row to the sheet
+func (h *SheetHandle) WriteRow(values []interface{}) error {
+	return h.writeRow(values, nil)
+}
+
+// WriteRows writes multiple rows to the sheet, encoding them concurrently in
+// pipelined mode (Config.ParallelWorkers)
+func (h *SheetHandle) WriteRows(rows [][]interface{}) error {
+	for len(rows) > 0 {
+		if err := h.checkWritable(); err != nil {
+			return err
+		}
+
+		n := min(len(rows), h.sw.config.MaxRowsPerSheet-h.sw.rowCount)
+		written, err := h.sw.writeRows(rows[:n], h.sw.config.ParallelWorkers)
+		h.rows += written
+		if err != nil {
+			return err
+		}
+		rows = rows[n:]
+	}
+	return nil
+}
+
+// WriteBatch writes the rows of a column batch to the sheet
+func (h *SheetHandle) WriteBatch(batch *ColumnBatch) error {
+	if err := batch.validate(h.sw.styles.cellStyleCount()); err != nil {
+		return err
+	}
+	styles, err := h.writer.batchStyles(batch)
+	if err != nil {
+		return err
+	}
+
+	for i := range batch.Len() {
+		if err := h.checkWritable(); err != nil {
+			return err
+		}
+		if err := h.sw.writeBatchRow(batch, i, styles); err != nil {
+			return err
+		}
+		h.rows++
+	}
+	return nil
+}
+
+// WriteRowWithOptions writes a row with outline level, visibility, height or
+// style attributes
+func (h *SheetHandle) WriteRowWithOptions(values []interface{}, opts RowOptions) error {
+	if err := opts.validate(h.sw.config.SheetOptions.MaxOutlineLevel, h.sw.styles.cellStyleCount()); err != nil {
+		return err
+	}
+	return h.writeRow(values, &opts)
+}
+
+// WriteSparseRow writes a row containing only the given cells, in strictly
+// ascending column order
+func (h *SheetHandle) WriteSparseRow(cells []ColumnValue) error {
+	if err := validateColumnValues(cells); err != nil {
+		return err
+	}
+	if err := h.checkWritable(); err != nil {
+		return err
+	}
+
+	if err := h.sw.writeSparseRow(cells); err != nil {
+		return err
+	}
+	h.rows++
+	return nil
+}
+
+// MergeCells merges a range of the sheet such as "A1:D1"
+func (h *SheetHandle) MergeCells(ref string) error {
+	if h.writer.finished {
+		return fmt.Errorf("file already finished")
+	}
+	r, err := parseCellRange(ref)
+	if err != nil {
+		return err
+	}
+	return h.sw.addMerge(r, h.sw.config.MaxRowsPerSheet)
+}
+
+// AddImage embeds a PNG, JPEG or GIF image anchored to a cell of the sheet,
+// like Writer.AddImage
+func (h *SheetHandle) AddImage(cell string, r io.Reader, opts *ImageOptions) error {
+	if h.writer.finished {
+		return fmt.Errorf("file already finished")
+	}
+	return h.sw.addImage(cell, r, opts, h.sw.config.MaxRowsPerSheet)
+}
+
+// writeRow writes a row after checking the sheet has room for it
+func (h *SheetHandle) writeRow(values []interface{}, opts *RowOptions) error {
+	if err := h.checkWritable(); err != nil {
+		return err
+	}
+	if err := h.sw.writeRow(values, opts); err != nil {
+		return err
+	}
+	h.rows++
+	return nil
+}
+
+// checkWritable verifies the file is open and the sheet has room for a row
+func (h *SheetHandle) checkWritable() error {
+	if h.writer.finished {
+		return fmt.Errorf("file already finished")
+	}
+	if h.sw.rowCount >= h.sw.config.MaxRowsPerSheet {
+		return fmt.Errorf("sheet %q is full (%d rows)", h.sw.name, h.sw.config.MaxRowsPerSheet)
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSheetHandlesConcurrent(t *testing.T) {
+	tmpFile := "test_sheet_handles.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 500
+	config.SpillThreshold = 1024 // spool most of each sheet to disk
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"Summary"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	orders, err := writer.Sheet("Orders", []interface{}{"Order", "Amount"})
+	if err != nil {
+		t.Fatalf("Failed to add sheet: %v", err)
+	}
+	customers, err := writer.Sheet("Customers")
+	if err != nil {
+		t.Fatalf("Failed to add sheet: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 300; i++ {
+			if err := orders.WriteRow([]interface{}{i, float64(i) * 1.5}); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 1; i <= 200; i++ {
+			style, err := writer.AddStyle(Style{Fill: &Fill{Color: fmt.Sprintf("%06X", i%4)}})
+			if err != nil {
+				errs <- err
+				return
+			}
+			if err := customers.WriteRow([]interface{}{fmt.Sprintf("Customer %d", i), Cell{Value: i, StyleID: style}}); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		// The Writer's own sheets roll over while the handles are written
+		for i := 1; i <= 700; i++ {
+			if err := writer.WriteRow([]interface{}{i}); err != nil {
+				errs <- err
+				return
+			}
+		}
+	}()
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatalf("Failed to write concurrently: %v", err)
+	}
+
+	if orders.Number() != 2 || customers.Name() != "Customers" {
+		t.Errorf("Unexpected handle number %d or name %q", orders.Number(), customers.Name())
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 1200 || stats.TotalSheets != 4 {
+		t.Errorf("Expected 1200 rows on 4 sheets, got %d rows on %d sheets", stats.TotalRows, stats.TotalSheets)
+	}
+
+	// Reading the entries verifies the raw-copied streams and their checksums
+	ordersXML := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	for _, e := range []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>Order</t></is></c>`,
+		`<row r="301"><c r="A301"><v>300</v></c><c r="B301"><v>450</v></c></row>`,
+		"</sheetData>\n</worksheet>",
+	} {
+		if !strings.Contains(ordersXML, e) {
+			t.Errorf("Expected %s in Orders sheet", e)
+		}
+	}
+	if !strings.Contains(readZipEntry(t, tmpFile, "xl/worksheets/sheet3.xml"), `<row r="200"><c r="A200" t="inlineStr"><is><t>Customer 200</t></is></c>`) {
+		t.Error("Expected 200 rows in Customers sheet")
+	}
+	if !strings.Contains(readZipEntry(t, tmpFile, "xl/worksheets/sheet4.xml"), `<row r="201"><c r="A201"><v>700</v></c></row>`) {
+		t.Error("Expected the rollover sheet after the named sheets")
+	}
+
+	workbook := readZipEntry(t, tmpFile, "xl/workbook.xml")
+	expected := `<sheet name="Sheet1" sheetId="1" r:id="rId1"/>
+<sheet name="Orders" sheetId="2" r:id="rId2"/>
+<sheet name="Customers" sheetId="3" r:id="rId3"/>
+<sheet name="Sheet2" sheetId="4" r:id="rId4"/>`
+	if !strings.Contains(workbook, expected) {
+		t.Errorf("Expected %s in workbook, got %s", expected, workbook)
+	}
+}
+
+func TestSheetHandleValidation(t *testing.T) {
+	tmpFile := "test_sheet_handle_invalid.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 2
+	config.SheetOptions.Table = &Table{}
+	writer := NewWriter(sink, config)
+
+	if _, err := writer.Sheet("Early"); err == nil {
+		t.Error("Expected error before StartFile")
+	}
+	if err := writer.StartFile([]interface{}{"ID", "Name"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	for _, name := range []string{"", strings.Repeat("x", 32), "a/b", "Q1:Q2", "'quoted'", "history", "sheet7"} {
+		if _, err := writer.Sheet(name); err == nil {
+			t.Errorf("Expected error for sheet name %q", name)
+		}
+	}
+
+	h, err := writer.Sheet("Lookup", []interface{}{"Code"})
+	if err != nil {
+		t.Fatalf("Failed to add sheet: %v", err)
+	}
+	if _, err := writer.Sheet("LOOKUP"); err == nil {
+		t.Error("Expected error for duplicate sheet name")
+	}
+
+	if err := h.WriteSparseRow([]ColumnValue{{Col: 2, Value: "x"}}); err != nil {
+		t.Fatalf("Failed to write sparse row: %v", err)
+	}
+	if err := h.WriteRow([]interface{}{"full"}); err == nil {
+		t.Error("Expected error once the named sheet is full")
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if err := h.WriteRow([]interface{}{"late"}); err == nil {
+		t.Error("Expected error after FinishFile")
+	}
+
+	// Tables apply only to the Writer's own sheets
+	lookup := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	if strings.Contains(lookup, "tableParts") {
+		t.Errorf("Expected no table on named sheet, got %s", lookup)
+	}
+}
@@ -0,0 +1,83 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SheetOptions configures worksheet features that are declared up front and
+// written when each sheet is closed
+type SheetOptions struct {
+	// Table makes every sheet's data an Excel Table
+	Table *Table
+
+	// Validations restricts what can be entered in columns, covering the
+	// data rows written to each sheet
+	Validations []DataValidation
+
+	// ConditionalFormats highlights cells by value, covering the data rows
+	// written to each sheet. Earlier rules have a higher priority.
+	ConditionalFormats []ConditionalFormat
+
+	// PageSetup configures printing: orientation, paper, scaling, margins,
+	// header and footer text and the print area
+	PageSetup *PageSetup
+
+	// Protection locks every sheet against editing
+	Protection *SheetProtection
+
+	// MaxOutlineLevel declares the deepest row outline level (1-7) used with
+	// WriteRowWithOptions, which Excel needs before the sheet's rows
+	MaxOutlineLevel int
+
+	// OutlineSummaryAbove places group summary rows above their detail rows
+	// instead of below
+	OutlineSummaryAbove bool
+}
+
+// validate checks the options before the first sheet is created
+func (o *SheetOptions) validate() error {
+	for i := range o.Validations {
+		if err := o.Validations[i].validate(); err != nil {
+			return fmt.Errorf("data validation %d: %w", i+1, err)
+		}
+	}
+	for i := range o.ConditionalFormats {
+		if err := o.ConditionalFormats[i].validate(); err != nil {
+			return fmt.Errorf("conditional format %d: %w", i+1, err)
+		}
+	}
+	if o.MaxOutlineLevel < 0 || o.MaxOutlineLevel > maxOutlineLevel {
+		return fmt.Errorf("max outline level must be between 0 and %d", maxOutlineLevel)
+	}
+	if o.PageSetup != nil {
+		if err := o.PageSetup.validate(); err != nil {
+			return fmt.Errorf("page setup: %w", err)
+		}
+	}
+	return nil
+}
+
+// generateWorksheetHeader generates the worksheet start up to <sheetData>,
+// including the sheet properties the options need
+func (o *SheetOptions) generateWorksheetHeader() string {
+	var header strings.Builder
+	header.WriteString(worksheetHeader)
+
+	var sheetPr strings.Builder
+	if o.MaxOutlineLevel > 0 {
+		sheetPr.WriteString(fmt.Sprintf(`<outlinePr summaryBelow="%s"/>`, boolAttr(!o.OutlineSummaryAbove)))
+	}
+	if o.PageSetup != nil && o.PageSetup.fitToPage() {
+		sheetPr.WriteString(`<pageSetUpPr fitToPage="1"/>`)
+	}
+	if sheetPr.Len() > 0 {
+		header.WriteString("<sheetPr>" + sheetPr.String() + "</sheetPr>\n")
+	}
+
+	if o.MaxOutlineLevel > 0 {
+		header.WriteString(fmt.Sprintf("<sheetFormatPr defaultRowHeight=\"15\" outlineLevelRow=\"%d\"/>\n", o.MaxOutlineLevel))
+	}
+	header.WriteString(sheetDataHeader)
+	return header.String()
+}
@@ -36,8 +36,57 @@ type Config struct {
 
 	// SheetNamePrefix is the prefix for auto-generated sheet names (default: "Sheet")
 	SheetNamePrefix string
+
+	// NonFiniteFloatPolicy controls how NaN and ±Inf float values are written
+	// (default: NonFiniteEmpty). Excel has no representation for them, so they
+	// must never reach the sheet XML as-is.
+	NonFiniteFloatPolicy NonFiniteFloatPolicy
+
+	// OmitEmptyCells skips nil values instead of writing empty <c> elements,
+	// shrinking sparse sheets. Styled empty cells are always written.
+	OmitEmptyCells bool
+
+	// SpillThreshold sets how many bytes of per-sheet collected XML (such as
+	// hyperlinks) are kept in memory before spilling to a temp file (default: 1MB)
+	SpillThreshold int
+
+	// TempDir is the directory for spill files (default: os.TempDir())
+	TempDir string
+
+	// ParallelWorkers enables pipelined mode when above 1: WriteRows batches
+	// are encoded to XML on up to this many goroutines, and parts are
+	// compressed as independent blocks on up to this many goroutines
+	// (default: 0, encode and compress on the calling goroutine)
+	ParallelWorkers int
+
+	// ParallelBlockSize is the uncompressed size of each block compressed on
+	// its own in pipelined mode (default: 1MB, minimum: 4KB)
+	ParallelBlockSize int
+
+	// SheetOptions holds the options applied to every sheet the writer creates
+	SheetOptions SheetOptions
+
+	// Properties sets the document properties written to docProps/
+	Properties DocumentProperties
+
+	// WorkbookProtection locks the workbook's structure or windows
+	WorkbookProtection *WorkbookProtection
 }
 
+// NonFiniteFloatPolicy selects how NaN and ±Inf float values are written
+type NonFiniteFloatPolicy int
+
+const (
+	// NonFiniteEmpty writes an empty cell
+	NonFiniteEmpty NonFiniteFloatPolicy = iota
+	// NonFiniteNumError writes a #NUM! error cell
+	NonFiniteNumError
+	// NonFiniteString writes "NaN", "+Inf" or "-Inf" as text
+	NonFiniteString
+	// NonFiniteError makes the write fail with an error
+	NonFiniteError
+)
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -45,5 +94,6 @@ func DefaultConfig() *Config {
 		BufferSize:       64 * 1024, // 64KB
 		MaxRowsPerSheet:  1048576,   // Excel's maximum rows per sheet
 		SheetNamePrefix:  "Sheet",
+		SpillThreshold:   defaultSpillThreshold,
 	}
 }
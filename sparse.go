@@ -0,0 +1,80 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// ColumnValue is a value at a zero-based column, for WriteSparseRow
+type ColumnValue struct {
+	Col   int
+	Value interface{}
+}
+
+// WriteSparseRow writes a row containing only the given cells, which must be
+// in strictly ascending column order. Columns that are not listed get no XML.
+func (w *Writer) WriteSparseRow(cells []ColumnValue) error {
+	if err := validateColumnValues(cells); err != nil {
+		return err
+	}
+
+	sw, err := w.rowSheet()
+	if err != nil {
+		return err
+	}
+
+	if err := sw.writeSparseRow(cells); err != nil {
+		return err
+	}
+	w.totalRows++
+
+	return nil
+}
+
+// validateColumnValues checks that columns are in range and strictly ascending
+func validateColumnValues(cells []ColumnValue) error {
+	for i, cell := range cells {
+		if cell.Col < 0 || cell.Col >= maxColumns {
+			return fmt.Errorf("column %d out of range", cell.Col)
+		}
+		if i > 0 && cell.Col <= cells[i-1].Col {
+			return fmt.Errorf("columns must be strictly ascending, got %d after %d", cell.Col, cells[i-1].Col)
+		}
+	}
+	return nil
+}
+
+// writeSparseRow generates and writes the XML for the sheet's next row from
+// cells at explicit columns
+func (sw *sheetWriter) writeSparseRow(cells []ColumnValue) error {
+	if len(cells) > 0 {
+		sw.growColumns(cells[len(cells)-1].Col + 1)
+	}
+	buf, err := sw.appendSparseRow(sw.buf[:0], sw.rowCount, cells)
+	sw.buf = buf
+	if err != nil {
+		sw.rowLinks = sw.rowLinks[:0]
+		return fmt.Errorf("failed to generate row: %w", err)
+	}
+	return sw.writeRowXML(buf)
+}
+
+// appendSparseRow appends the XML of a row with cells at explicit columns,
+// followed by a newline
+func (sw *sheetWriter) appendSparseRow(dst []byte, rowIndex int, cells []ColumnValue) ([]byte, error) {
+	dst = append(dst, `<row r="`...)
+	dst = strconv.AppendInt(dst, int64(rowIndex+1), 10)
+	dst = append(dst, `">`...)
+
+	for _, c := range cells {
+		if c.Value == nil && sw.config.OmitEmptyCells {
+			continue
+		}
+		var err error
+		if dst, err = sw.appendCell(dst, sw.cellRef(rowIndex, c.Col), 0, c.Value); err != nil {
+			return dst, err
+		}
+	}
+
+	return append(dst, "</row>\n"...), nil
+}
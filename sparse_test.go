@@ -0,0 +1,95 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteSparseRow(t *testing.T) {
+	tmpFile := "test_sparse.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 3
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	if err := writer.WriteSparseRow([]ColumnValue{{Col: 0, Value: 1}, {Col: 25, Value: "Z"}, {Col: 16383, Value: true}}); err != nil {
+		t.Fatalf("Failed to write sparse row: %v", err)
+	}
+	if err := writer.WriteSparseRow([]ColumnValue{{Col: 2, Value: nil}}); err != nil {
+		t.Fatalf("Failed to write sparse row: %v", err)
+	}
+
+	invalid := [][]ColumnValue{
+		{{Col: -1, Value: 1}},
+		{{Col: 16384, Value: 1}},
+		{{Col: 3, Value: 1}, {Col: 3, Value: 2}},
+		{{Col: 3, Value: 1}, {Col: 1, Value: 2}},
+	}
+	for _, cells := range invalid {
+		if err := writer.WriteSparseRow(cells); err == nil {
+			t.Errorf("Expected error for cells %v", cells)
+		}
+	}
+
+	// Sparse rows roll over like WriteRow
+	if err := writer.WriteSparseRow([]ColumnValue{{Col: 1, Value: 2.5}}); err != nil {
+		t.Fatalf("Failed to write sparse row: %v", err)
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 3 || stats.TotalSheets != 2 {
+		t.Errorf("Expected 3 rows on 2 sheets, got %d rows on %d sheets", stats.TotalRows, stats.TotalSheets)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	for _, e := range []string{
+		`<row r="2"><c r="A2"><v>1</v></c><c r="Z2" t="inlineStr"><is><t>Z</t></is></c><c r="XFD2" t="b"><v>1</v></c></row>`,
+		`<row r="3"><c r="C3"/></row>`,
+	} {
+		if !strings.Contains(sheet1, e) {
+			t.Errorf("Expected %s in sheet 1, got %s", e, sheet1)
+		}
+	}
+
+	sheet2 := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	if !strings.Contains(sheet2, `<row r="1"><c r="B1"><v>2.5</v></c></row>`) {
+		t.Errorf("Expected sparse row on sheet 2, got %s", sheet2)
+	}
+}
+
+func TestOmitEmptyCells(t *testing.T) {
+	config := DefaultConfig()
+	config.OmitEmptyCells = true
+	sw := newTestSheetWriter(config)
+
+	rowXML, err := generateRowXML(sw, 0, []interface{}{nil, "a", nil, Cell{Value: nil, StyleID: 1}, nil})
+	if err != nil {
+		t.Fatalf("Failed to generate row: %v", err)
+	}
+	expected := `<row r="1"><c r="B1" t="inlineStr"><is><t>a</t></is></c><c r="D1" s="1"/></row>`
+	if rowXML != expected {
+		t.Errorf("Expected %s, got %s", expected, rowXML)
+	}
+
+	sparseXML, err := sw.appendSparseRow(nil, 0, []ColumnValue{{Col: 4, Value: nil}})
+	if err != nil {
+		t.Fatalf("Failed to generate row: %v", err)
+	}
+	if rowXML = string(sparseXML); rowXML != "<row r=\"1\"></row>\n" {
+		t.Errorf("Expected empty row, got %s", rowXML)
+	}
+}
@@ -0,0 +1,125 @@
+package kolayxlsxstream
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// defaultSpillThreshold is the number of bytes a spill buffer keeps in
+// memory when Config.SpillThreshold is not set
+const defaultSpillThreshold = 1024 * 1024
+
+// spillBuffer collects XML fragments in memory and moves them to a temporary
+// file once they exceed a threshold, so per-sheet collections such as
+// hyperlinks stay bounded in memory regardless of sheet size
+type spillBuffer struct {
+	threshold int
+	dir       string
+
+	mem  bytes.Buffer
+	file *os.File
+	bw   *bufio.Writer
+	size int64
+}
+
+// newSpillBuffer creates a spill buffer that keeps up to threshold bytes in
+// memory before spilling to a temporary file in dir. A zero threshold uses
+// the default.
+func newSpillBuffer(threshold int, dir string) *spillBuffer {
+	if threshold == 0 {
+		threshold = defaultSpillThreshold
+	}
+	return &spillBuffer{
+		threshold: threshold,
+		dir:       dir,
+	}
+}
+
+// Write implements io.Writer interface
+func (sb *spillBuffer) Write(p []byte) (int, error) {
+	sb.size += int64(len(p))
+
+	if sb.file != nil {
+		return sb.bw.Write(p)
+	}
+
+	n, err := sb.mem.Write(p)
+	if err != nil || sb.mem.Len() <= sb.threshold {
+		return n, err
+	}
+
+	// Threshold exceeded: move everything collected so far to disk
+	file, err := os.CreateTemp(sb.dir, "kolayxlsxstream-*.tmp")
+	if err != nil {
+		return n, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	sb.file = file
+	sb.bw = bufio.NewWriter(file)
+	if _, err := sb.mem.WriteTo(sb.bw); err != nil {
+		return n, fmt.Errorf("failed to write spill file: %w", err)
+	}
+	sb.mem = bytes.Buffer{}
+
+	return n, nil
+}
+
+// WriteString writes a string to the buffer
+func (sb *spillBuffer) WriteString(s string) (int, error) {
+	return sb.Write([]byte(s))
+}
+
+// Len returns the total number of bytes written
+func (sb *spillBuffer) Len() int64 {
+	return sb.size
+}
+
+// WriteTo copies everything written so far to w
+func (sb *spillBuffer) WriteTo(w io.Writer) (int64, error) {
+	if sb.file == nil {
+		return io.Copy(w, bytes.NewReader(sb.mem.Bytes()))
+	}
+
+	if err := sb.bw.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to flush spill file: %w", err)
+	}
+	if _, err := sb.file.Seek(0, io.SeekStart); err != nil {
+		return 0, fmt.Errorf("failed to rewind spill file: %w", err)
+	}
+	n, err := io.Copy(w, sb.file)
+	if _, seekErr := sb.file.Seek(0, io.SeekEnd); err == nil && seekErr != nil {
+		err = seekErr
+	}
+	return n, err
+}
+
+// WriteSectionTo copies n bytes written at offset off to w
+func (sb *spillBuffer) WriteSectionTo(w io.Writer, off, n int64) (int64, error) {
+	if sb.file == nil {
+		return io.Copy(w, bytes.NewReader(sb.mem.Bytes()[off:off+n]))
+	}
+
+	if err := sb.bw.Flush(); err != nil {
+		return 0, fmt.Errorf("failed to flush spill file: %w", err)
+	}
+	return io.Copy(w, io.NewSectionReader(sb.file, off, n))
+}
+
+// Close releases the buffer and removes the temporary file, if any
+func (sb *spillBuffer) Close() error {
+	sb.mem = bytes.Buffer{}
+	if sb.file == nil {
+		return nil
+	}
+
+	name := sb.file.Name()
+	err := sb.file.Close()
+	if removeErr := os.Remove(name); err == nil {
+		err = removeErr
+	}
+	sb.file = nil
+	sb.bw = nil
+	return err
+}
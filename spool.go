@@ -0,0 +1,64 @@
+package kolayxlsxstream
+
+import (
+	"archive/zip"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+)
+
+// sheetSpool compresses a sheet's XML into a spill buffer, so the sheet can be
+// written independently of the archive and copied into it with CreateRaw
+type sheetSpool struct {
+	data  *spillBuffer
+	flate io.WriteCloser
+	crc   hash.Hash32
+	size  uint64
+}
+
+// newSheetSpool creates a spool compressing with the Writer's compressor
+func newSheetSpool(config *Config, compress func(io.Writer) (io.WriteCloser, error)) (*sheetSpool, error) {
+	data := newSpillBuffer(config.SpillThreshold, config.TempDir)
+	fw, err := compress(data)
+	if err != nil {
+		data.Close()
+		return nil, fmt.Errorf("failed to create compressor: %w", err)
+	}
+	return &sheetSpool{data: data, flate: fw, crc: crc32.NewIEEE()}, nil
+}
+
+// Write compresses p into the spool, tracking the checksum and size of the
+// uncompressed stream for the ZIP header
+func (s *sheetSpool) Write(p []byte) (int, error) {
+	s.crc.Write(p)
+	s.size += uint64(len(p))
+	return s.flate.Write(p)
+}
+
+// copyTo finishes the compressed stream and copies it into the archive
+func (s *sheetSpool) copyTo(zw *zip.Writer, name string) error {
+	if err := s.flate.Close(); err != nil {
+		return fmt.Errorf("failed to compress %s: %w", name, err)
+	}
+
+	entry, err := zw.CreateRaw(&zip.FileHeader{
+		Name:               name,
+		Method:             zip.Deflate,
+		CRC32:              s.crc.Sum32(),
+		CompressedSize64:   uint64(s.data.Len()),
+		UncompressedSize64: s.size,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", name, err)
+	}
+	if _, err := s.data.WriteTo(entry); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
+
+// Close removes the spool's temporary file
+func (s *sheetSpool) Close() error {
+	return s.data.Close()
+}
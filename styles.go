@@ -0,0 +1,364 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Style describes the formatting of a cell. Register it with
+// Writer.AddStyle and apply the returned ID through Cell.StyleID.
+type Style struct {
+	Font      *Font
+	Fill      *Fill
+	Border    *Border
+	Alignment *Alignment
+
+	// Protection sets whether the cell is locked or its formula hidden when
+	// the sheet is protected (default: locked)
+	Protection *CellProtection
+
+	// NumFmt is a number format code, e.g. "#,##0.00" or "yyyy-mm-dd"
+	NumFmt string
+}
+
+// Fill is a solid background fill
+type Fill struct {
+	Color string // RGB or ARGB hex color
+}
+
+// Border draws the same line on all four sides of a cell
+type Border struct {
+	Style string // "thin", "medium", "thick", "dashed", "dotted" or "double"
+	Color string // RGB or ARGB hex color (default: black)
+}
+
+// Alignment sets the position of text within a cell
+type Alignment struct {
+	Horizontal string // "left", "center", "right", "fill", "justify", ...
+	Vertical   string // "top", "center", "bottom", ...
+	WrapText   bool
+}
+
+// firstCustomNumFmtID is the first ID available for custom number formats
+const firstCustomNumFmtID = 164
+
+// builtinNumFmts maps format codes to Excel's built-in number format IDs
+var builtinNumFmts = map[string]int{
+	"General":     0,
+	"0":           1,
+	"0.00":        2,
+	"#,##0":       3,
+	"#,##0.00":    4,
+	"0%":          9,
+	"0.00%":       10,
+	"0.00E+00":    11,
+	"mm-dd-yy":    14,
+	"d-mmm-yy":    15,
+	"h:mm":        20,
+	"h:mm:ss":     21,
+	"m/d/yy h:mm": 22,
+	"@":           49,
+}
+
+var borderStyles = map[string]bool{
+	"thin": true, "medium": true, "thick": true, "dashed": true, "dotted": true, "double": true,
+}
+
+// styleSheet collects the fonts, fills, borders, number formats, cell formats
+// and differential formats of the generated xl/styles.xml part
+type styleSheet struct {
+	mu sync.Mutex // Styles may be added while sheets are written concurrently
+
+	numFmts []string
+	fonts   []string
+	fills   []string
+	borders []string
+	cellXfs []string
+	dxfs    []string
+
+	numFmtIDs map[string]int
+	index     map[string]int // Deduplicates fonts, fills, borders and cellXfs
+}
+
+// newStyleSheet creates a style sheet holding the built-in styles
+func newStyleSheet() *styleSheet {
+	ss := &styleSheet{
+		numFmtIDs: make(map[string]int),
+		index:     make(map[string]int),
+	}
+
+	ss.add(&ss.fonts, "font", `<font><sz val="11"/><name val="Calibri"/></font>`)
+	ss.add(&ss.fonts, "font", `<font><u/><sz val="11"/><color rgb="FF0563C1"/><name val="Calibri"/></font>`)
+	ss.add(&ss.fills, "fill", `<fill><patternFill patternType="none"/></fill>`)
+	ss.add(&ss.fills, "fill", `<fill><patternFill patternType="gray125"/></fill>`)
+	ss.add(&ss.borders, "border", `<border><left/><right/><top/><bottom/><diagonal/></border>`)
+	ss.add(&ss.cellXfs, "xf", `<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>`)
+	ss.add(&ss.cellXfs, "xf", `<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>`) // hyperlinkStyleID
+
+	return ss
+}
+
+// add appends an element to a list unless an identical one exists and
+// returns its index
+func (ss *styleSheet) add(list *[]string, kind, element string) int {
+	key := kind + element
+	if i, ok := ss.index[key]; ok {
+		return i
+	}
+	*list = append(*list, element)
+	ss.index[key] = len(*list) - 1
+	return len(*list) - 1
+}
+
+// numFmtID returns the ID of a number format code, registering custom codes
+func (ss *styleSheet) numFmtID(code string) int {
+	if id, ok := builtinNumFmts[code]; ok {
+		return id
+	}
+	if id, ok := ss.numFmtIDs[code]; ok {
+		return id
+	}
+	id := firstCustomNumFmtID + len(ss.numFmts)
+	ss.numFmtIDs[code] = id
+	ss.numFmts = append(ss.numFmts, fmt.Sprintf(`<numFmt numFmtId="%d" formatCode="%s"/>`, id, escapeXML(code)))
+	return id
+}
+
+// addCellStyle registers a cell format and returns its cellXfs index
+func (ss *styleSheet) addCellStyle(s Style) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var xf strings.Builder
+	var inner strings.Builder
+
+	numFmtID, fontID, fillID, borderID := 0, 0, 0, 0
+	if s.NumFmt != "" {
+		numFmtID = ss.numFmtID(s.NumFmt)
+	}
+	if s.Font != nil {
+		font, err := generateFontXML(s.Font, true)
+		if err != nil {
+			return 0, err
+		}
+		fontID = ss.add(&ss.fonts, "font", font)
+	}
+	if s.Fill != nil {
+		color, err := normalizeColor(s.Fill.Color)
+		if err != nil {
+			return 0, err
+		}
+		fillID = ss.add(&ss.fills, "fill", fmt.Sprintf(
+			`<fill><patternFill patternType="solid"><fgColor rgb="%s"/><bgColor indexed="64"/></patternFill></fill>`, color))
+	}
+	if s.Border != nil {
+		border, err := generateBorderXML(s.Border)
+		if err != nil {
+			return 0, err
+		}
+		borderID = ss.add(&ss.borders, "border", border)
+	}
+
+	xf.WriteString(fmt.Sprintf(`<xf numFmtId="%d" fontId="%d" fillId="%d" borderId="%d" xfId="0"`,
+		numFmtID, fontID, fillID, borderID))
+	if s.NumFmt != "" {
+		xf.WriteString(` applyNumberFormat="1"`)
+	}
+	if s.Font != nil {
+		xf.WriteString(` applyFont="1"`)
+	}
+	if s.Fill != nil {
+		xf.WriteString(` applyFill="1"`)
+	}
+	if s.Border != nil {
+		xf.WriteString(` applyBorder="1"`)
+	}
+	if s.Alignment != nil {
+		xf.WriteString(` applyAlignment="1"`)
+		inner.WriteString(generateAlignmentXML(s.Alignment))
+	}
+	if s.Protection != nil {
+		xf.WriteString(` applyProtection="1"`)
+		inner.WriteString(generateProtectionXML(s.Protection))
+	}
+
+	if inner.Len() > 0 {
+		xf.WriteString(">" + inner.String() + "</xf>")
+	} else {
+		xf.WriteString("/>")
+	}
+
+	return ss.add(&ss.cellXfs, "xf", xf.String()), nil
+}
+
+// addDifferentialStyle registers a differential format, as used by
+// conditional formatting, and returns its dxfs index
+func (ss *styleSheet) addDifferentialStyle(s Style) (int, error) {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var dxf strings.Builder
+	dxf.WriteString(`<dxf>`)
+	if s.Font != nil {
+		font, err := generateFontXML(s.Font, false)
+		if err != nil {
+			return 0, err
+		}
+		dxf.WriteString(font)
+	}
+	if s.NumFmt != "" {
+		dxf.WriteString(fmt.Sprintf(`<numFmt numFmtId="%d" formatCode="%s"/>`, ss.numFmtID(s.NumFmt), escapeXML(s.NumFmt)))
+	}
+	if s.Fill != nil {
+		color, err := normalizeColor(s.Fill.Color)
+		if err != nil {
+			return 0, err
+		}
+		// Differential fills take their color from bgColor
+		dxf.WriteString(fmt.Sprintf(`<fill><patternFill patternType="solid"><bgColor rgb="%s"/></patternFill></fill>`, color))
+	}
+	if s.Alignment != nil {
+		dxf.WriteString(generateAlignmentXML(s.Alignment))
+	}
+	if s.Border != nil {
+		border, err := generateBorderXML(s.Border)
+		if err != nil {
+			return 0, err
+		}
+		dxf.WriteString(border)
+	}
+	dxf.WriteString(`</dxf>`)
+
+	ss.dxfs = append(ss.dxfs, dxf.String())
+	return len(ss.dxfs) - 1, nil
+}
+
+// cellStyleCount returns the number of registered cell formats
+func (ss *styleSheet) cellStyleCount() int {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	return len(ss.cellXfs)
+}
+
+// generateXML generates the xl/styles.xml part
+func (ss *styleSheet) generateXML() string {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	var styles strings.Builder
+	styles.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
+`)
+	writeList := func(name string, items []string) {
+		styles.WriteString(fmt.Sprintf(`<%s count="%d">`, name, len(items)))
+		for _, item := range items {
+			styles.WriteString(item)
+		}
+		styles.WriteString(fmt.Sprintf("</%s>\n", name))
+	}
+
+	if len(ss.numFmts) > 0 {
+		writeList("numFmts", ss.numFmts)
+	}
+	writeList("fonts", ss.fonts)
+	writeList("fills", ss.fills)
+	writeList("borders", ss.borders)
+	styles.WriteString(`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
+`)
+	writeList("cellXfs", ss.cellXfs)
+	if len(ss.dxfs) > 0 {
+		writeList("dxfs", ss.dxfs)
+	}
+	styles.WriteString(`</styleSheet>`)
+
+	return styles.String()
+}
+
+// generateFontXML generates a <font> element. Cell fonts always name a size
+// and typeface; differential fonts only carry what they change.
+func generateFontXML(f *Font, complete bool) (string, error) {
+	var font strings.Builder
+	font.WriteString(`<font>`)
+	if f.Bold {
+		font.WriteString(`<b/>`)
+	}
+	if f.Italic {
+		font.WriteString(`<i/>`)
+	}
+	if f.Strike {
+		font.WriteString(`<strike/>`)
+	}
+	if f.Underline {
+		font.WriteString(`<u/>`)
+	}
+	size := f.Size
+	if size <= 0 && complete {
+		size = 11
+	}
+	if size > 0 {
+		font.WriteString(fmt.Sprintf(`<sz val="%s"/>`, strconv.FormatFloat(size, 'f', -1, 64)))
+	}
+	if f.Color != "" {
+		color, err := normalizeColor(f.Color)
+		if err != nil {
+			return "", err
+		}
+		font.WriteString(fmt.Sprintf(`<color rgb="%s"/>`, color))
+	}
+	name := f.Name
+	if name == "" && complete {
+		name = "Calibri"
+	}
+	if name != "" {
+		font.WriteString(fmt.Sprintf(`<name val="%s"/>`, escapeXML(name)))
+	}
+	font.WriteString(`</font>`)
+	return font.String(), nil
+}
+
+// generateBorderXML generates a <border> element with the same line on all sides
+func generateBorderXML(b *Border) (string, error) {
+	if !borderStyles[b.Style] {
+		return "", fmt.Errorf("unknown border style %q", b.Style)
+	}
+	color := "FF000000"
+	if b.Color != "" {
+		var err error
+		if color, err = normalizeColor(b.Color); err != nil {
+			return "", err
+		}
+	}
+
+	side := fmt.Sprintf(` style="%s"><color rgb="%s"/>`, b.Style, color)
+	return fmt.Sprintf(`<border><left%s</left><right%s</right><top%s</top><bottom%s</bottom><diagonal/></border>`,
+		side, side, side, side), nil
+}
+
+// generateAlignmentXML generates an <alignment> element
+func generateAlignmentXML(a *Alignment) string {
+	var alignment strings.Builder
+	alignment.WriteString(`<alignment`)
+	if a.Horizontal != "" {
+		alignment.WriteString(fmt.Sprintf(` horizontal="%s"`, escapeXML(a.Horizontal)))
+	}
+	if a.Vertical != "" {
+		alignment.WriteString(fmt.Sprintf(` vertical="%s"`, escapeXML(a.Vertical)))
+	}
+	if a.WrapText {
+		alignment.WriteString(` wrapText="1"`)
+	}
+	alignment.WriteString(`/>`)
+	return alignment.String()
+}
+
+// AddStyle registers a cell style and returns the ID to use in Cell.StyleID.
+// Identical styles share an ID.
+func (w *Writer) AddStyle(s Style) (int, error) {
+	if w.finished {
+		return 0, fmt.Errorf("file already finished")
+	}
+	return w.styles.addCellStyle(s)
+}
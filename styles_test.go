@@ -0,0 +1,95 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestAddStyle(t *testing.T) {
+	tmpFile := "test_styles.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	writer := NewWriter(sink)
+
+	header, err := writer.AddStyle(Style{
+		Font:      &Font{Bold: true, Color: "FFFFFF"},
+		Fill:      &Fill{Color: "4472C4"},
+		Border:    &Border{Style: "thin"},
+		Alignment: &Alignment{Horizontal: "center"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+
+	money, err := writer.AddStyle(Style{NumFmt: "#,##0.00 \"USD\""})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+
+	percent, err := writer.AddStyle(Style{NumFmt: "0%"})
+	if err != nil {
+		t.Fatalf("Failed to add style: %v", err)
+	}
+
+	// Identical styles share an ID
+	if again, _ := writer.AddStyle(Style{NumFmt: "0%"}); again != percent {
+		t.Errorf("Expected identical style to reuse ID %d, got %d", percent, again)
+	}
+
+	if _, err := writer.AddStyle(Style{Border: &Border{Style: "wavy"}}); err == nil {
+		t.Error("Expected error for unknown border style")
+	}
+	if _, err := writer.AddStyle(Style{Fill: &Fill{Color: "blue"}}); err == nil {
+		t.Error("Expected error for invalid fill color")
+	}
+
+	if err := writer.StartFile([]interface{}{Cell{Value: "Amount", StyleID: header}, Cell{Value: "Share", StyleID: header}}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	if err := writer.WriteRow([]interface{}{Cell{Value: 1234.5, StyleID: money}, Cell{Value: 0.25, StyleID: percent}, Cell{StyleID: header}}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+
+	if err := writer.WriteRow([]interface{}{Cell{Value: 1, StyleID: 99}}); err == nil {
+		t.Error("Expected error for unknown style ID")
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheetXML := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := []string{
+		`<c r="A1" s="2" t="inlineStr"><is><t>Amount</t></is></c>`,
+		`<c r="A2" s="3"><v>1234.5</v></c><c r="B2" s="4"><v>0.25</v></c><c r="C2" s="2"/>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheetXML, e) {
+			t.Errorf("Expected %s in sheet XML, got %s", e, sheetXML)
+		}
+	}
+
+	stylesXML := readZipEntry(t, tmpFile, "xl/styles.xml")
+	expectedStyles := []string{
+		`<numFmts count="1"><numFmt numFmtId="164" formatCode="#,##0.00 &#34;USD&#34;"/></numFmts>`,
+		`<font><b/><sz val="11"/><color rgb="FFFFFFFF"/><name val="Calibri"/></font>`,
+		`<fill><patternFill patternType="solid"><fgColor rgb="FF4472C4"/><bgColor indexed="64"/></patternFill></fill>`,
+		`<border><left style="thin"><color rgb="FF000000"/></left>`,
+		`<cellXfs count="5">`,
+		`<xf numFmtId="0" fontId="2" fillId="2" borderId="1" xfId="0" applyFont="1" applyFill="1" applyBorder="1" applyAlignment="1"><alignment horizontal="center"/></xf>`,
+		`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`,
+		`<xf numFmtId="9" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>`,
+	}
+	for _, e := range expectedStyles {
+		if !strings.Contains(stylesXML, e) {
+			t.Errorf("Expected %s in styles XML, got %s", e, stylesXML)
+		}
+	}
+}
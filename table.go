@@ -0,0 +1,238 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Table turns each sheet's data into an Excel Table (ListObject) so filters,
+// slicers and structured references work. Column names are taken from the
+// header row passed to StartFile, which is repeated on every sheet.
+type Table struct {
+	// Name is the table name used in structured references (default: "Table1").
+	// Tables on sheets created by rollover get a "_2", "_3", ... suffix.
+	Name string
+
+	// Style is the table style name (default: "TableStyleMedium2")
+	Style string
+
+	ShowRowStripes    bool
+	ShowColumnStripes bool
+	ShowFirstColumn   bool
+	ShowLastColumn    bool
+
+	// TotalsRow adds a totals row below the data when the sheet is closed
+	TotalsRow bool
+
+	// TotalsRowLabel is the label in the first column of the totals row
+	// (default: "Total"), unless that column has a totals function
+	TotalsRowLabel string
+
+	// TotalsRowFunctions maps column names to the totals row function:
+	// "sum", "average", "count", "countNums", "max", "min", "stdDev" or "var"
+	TotalsRowFunctions map[string]string
+}
+
+const (
+	relTypeTable     = "http://schemas.openxmlformats.org/officeDocument/2006/relationships/table"
+	contentTypeTable = "application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"
+
+	defaultTableStyle = "TableStyleMedium2"
+)
+
+// subtotalFunctions maps totals row functions to SUBTOTAL function numbers
+// that ignore filtered-out rows
+var subtotalFunctions = map[string]int{
+	"average":   101,
+	"countNums": 102,
+	"count":     103,
+	"max":       104,
+	"min":       105,
+	"stdDev":    107,
+	"sum":       109,
+	"var":       110,
+}
+
+var (
+	tableNamePattern = regexp.MustCompile(`^[\p{L}_\\][\p{L}\p{N}_.]*$`)
+	cellLikeName     = regexp.MustCompile(`^(?i)([a-z]{1,3}[0-9]+|[rc]|r[0-9]*c[0-9]*)$`)
+)
+
+// validate checks the table options against the header row and returns the
+// table column names
+func (t *Table) validate(headers []interface{}) ([]string, error) {
+	if len(headers) == 0 {
+		return nil, fmt.Errorf("table requires a header row")
+	}
+
+	if t.Name != "" {
+		if len(t.Name) > 250 || !tableNamePattern.MatchString(t.Name) || cellLikeName.MatchString(t.Name) {
+			return nil, fmt.Errorf("invalid table name %q", t.Name)
+		}
+	}
+
+	columns := tableColumnNames(headers)
+
+	for name, function := range t.TotalsRowFunctions {
+		if _, ok := subtotalFunctions[function]; !ok {
+			return nil, fmt.Errorf("unknown totals row function %q for column %q", function, name)
+		}
+		found := false
+		for _, column := range columns {
+			found = found || column == name
+		}
+		if !found {
+			return nil, fmt.Errorf("totals row function for unknown column %q", name)
+		}
+	}
+
+	return columns, nil
+}
+
+// tableColumnNames converts header values to the unique, non-empty column
+// names a table requires
+func tableColumnNames(headers []interface{}) []string {
+	columns := make([]string, len(headers))
+	seen := make(map[string]bool, len(headers))
+
+	for i, header := range headers {
+		name := ""
+		if header != nil {
+			name = strings.TrimSpace(formatText(header))
+		}
+		if name == "" {
+			name = fmt.Sprintf("Column%d", i+1)
+		}
+
+		unique := name
+		for n := 2; seen[strings.ToLower(unique)]; n++ {
+			unique = fmt.Sprintf("%s%d", name, n)
+		}
+		seen[strings.ToLower(unique)] = true
+		columns[i] = unique
+	}
+
+	return columns
+}
+
+// tableName returns the name of the table with the given workbook-wide ID
+func (t *Table) tableName(id int) string {
+	if t.Name == "" {
+		return fmt.Sprintf("Table%d", id)
+	}
+	if id == 1 {
+		return t.Name
+	}
+	return fmt.Sprintf("%s_%d", t.Name, id)
+}
+
+// totalsRowLabel returns the label for the first column of the totals row
+func (t *Table) totalsRowLabel() string {
+	if t.TotalsRowLabel == "" {
+		return "Total"
+	}
+	return t.TotalsRowLabel
+}
+
+// writeTable assigns the sheet's table an ID and name and writes its totals
+// row. It must be called before </sheetData>.
+func (w *Writer) writeTable(sw *sheetWriter) (id int, name string, err error) {
+	t := w.config.SheetOptions.Table
+
+	w.tableCount++
+	id = w.tableCount
+	name = t.tableName(id)
+
+	// A table needs at least one data row below the header
+	if sw.rowCount < 2 {
+		sw.rowCount = 2
+	}
+
+	if t.TotalsRow {
+		values := make([]interface{}, len(w.tableColumns))
+		for i, column := range w.tableColumns {
+			if function, ok := t.TotalsRowFunctions[column]; ok {
+				values[i] = formula(fmt.Sprintf("SUBTOTAL(%d,%s[%s])",
+					subtotalFunctions[function], name, escapeStructuredReference(column)))
+			} else if i == 0 {
+				values[i] = t.totalsRowLabel()
+			}
+		}
+
+		if err := sw.writeRow(values, nil); err != nil {
+			return 0, "", fmt.Errorf("failed to write totals row: %w", err)
+		}
+	}
+
+	return id, name, nil
+}
+
+// generateTableXML generates the xl/tables/tableN.xml part for a sheet
+func (w *Writer) generateTableXML(sw *sheetWriter, id int, name string) string {
+	t := w.config.SheetOptions.Table
+	lastCol := len(w.tableColumns) - 1
+	lastRow := sw.rowCount - 1
+
+	var table strings.Builder
+	table.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+`)
+	table.WriteString(fmt.Sprintf(`<table xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" id="%d" name="%s" displayName="%s" ref="%s"`,
+		id, name, name, cellRange{toRow: lastRow, toCol: lastCol}))
+	if t.TotalsRow {
+		table.WriteString(` totalsRowCount="1"`)
+		lastRow--
+	} else {
+		table.WriteString(` totalsRowShown="0"`)
+	}
+	table.WriteString(">\n")
+
+	table.WriteString(fmt.Sprintf(`<autoFilter ref="%s"/>
+`, cellRange{toRow: lastRow, toCol: lastCol}))
+
+	table.WriteString(fmt.Sprintf(`<tableColumns count="%d">`, len(w.tableColumns)))
+	for i, column := range w.tableColumns {
+		table.WriteString(fmt.Sprintf(`<tableColumn id="%d" name="%s"`, i+1, escapeXML(column)))
+		if t.TotalsRow {
+			if function, ok := t.TotalsRowFunctions[column]; ok {
+				table.WriteString(fmt.Sprintf(` totalsRowFunction="%s"`, function))
+			} else if i == 0 {
+				table.WriteString(fmt.Sprintf(` totalsRowLabel="%s"`, escapeXML(t.totalsRowLabel())))
+			}
+		}
+		table.WriteString(`/>`)
+	}
+	table.WriteString("</tableColumns>\n")
+
+	style := t.Style
+	if style == "" {
+		style = defaultTableStyle
+	}
+	table.WriteString(fmt.Sprintf(`<tableStyleInfo name="%s" showFirstColumn="%s" showLastColumn="%s" showRowStripes="%s" showColumnStripes="%s"/>
+`, escapeXML(style), boolAttr(t.ShowFirstColumn), boolAttr(t.ShowLastColumn), boolAttr(t.ShowRowStripes), boolAttr(t.ShowColumnStripes)))
+
+	table.WriteString(`</table>`)
+	return table.String()
+}
+
+// escapeStructuredReference escapes the characters that have a special
+// meaning inside a structured reference column specifier
+func escapeStructuredReference(column string) string {
+	var escaped strings.Builder
+	for _, r := range column {
+		switch r {
+		case '[', ']', '#', '\'':
+			escaped.WriteRune('\'')
+		}
+		escaped.WriteRune(r)
+	}
+	return escaped.String()
+}
+
+// boolAttr formats a boolean XML attribute value
+func boolAttr(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,136 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTable(t *testing.T) {
+	tmpFile := "test_table.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 5
+	config.SheetOptions.Table = &Table{
+		Name:               "Orders",
+		ShowRowStripes:     true,
+		TotalsRow:          true,
+		TotalsRowFunctions: map[string]string{"Amount [USD]": "sum"},
+	}
+
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID", "Amount [USD]", "ID", nil}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	// Sheet 1 holds the header, 3 data rows and the totals row
+	for i := 1; i <= 4; i++ {
+		if err := writer.WriteRow([]interface{}{i, float64(i) * 10, "x", "y"}); err != nil {
+			t.Fatalf("Failed to write row %d: %v", i, err)
+		}
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	if stats.TotalSheets != 2 || stats.TotalRows != 4 {
+		t.Errorf("Expected 2 sheets and 4 rows, got %d sheets and %d rows", stats.TotalSheets, stats.TotalRows)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expectedSheet1 := []string{
+		`<c r="A1" t="inlineStr"><is><t>ID</t></is></c><c r="B1" t="inlineStr"><is><t>Amount [USD]</t></is></c><c r="C1" t="inlineStr"><is><t>ID2</t></is></c><c r="D1" t="inlineStr"><is><t>Column4</t></is></c>`,
+		`<row r="5"><c r="A5" t="inlineStr"><is><t>Total</t></is></c><c r="B5"><f>SUBTOTAL(109,Orders[Amount &#39;[USD&#39;]])</f></c><c r="C5"/><c r="D5"/></row>`,
+		`<tableParts count="1"><tablePart r:id="rId1"/></tableParts>
+</worksheet>`,
+	}
+	for _, e := range expectedSheet1 {
+		if !strings.Contains(sheet1, e) {
+			t.Errorf("Expected %s in sheet 1, got %s", e, sheet1)
+		}
+	}
+
+	// The header row is repeated on the rollover sheet
+	sheet2 := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	if !strings.Contains(sheet2, `<row r="1"><c r="A1" t="inlineStr"><is><t>ID</t></is></c>`) {
+		t.Errorf("Expected header row in sheet 2, got %s", sheet2)
+	}
+
+	table1 := readZipEntry(t, tmpFile, "xl/tables/table1.xml")
+	expectedTable1 := []string{
+		`id="1" name="Orders" displayName="Orders" ref="A1:D5" totalsRowCount="1">`,
+		`<autoFilter ref="A1:D4"/>`,
+		`<tableColumn id="1" name="ID" totalsRowLabel="Total"/><tableColumn id="2" name="Amount [USD]" totalsRowFunction="sum"/>`,
+		`<tableStyleInfo name="TableStyleMedium2" showFirstColumn="0" showLastColumn="0" showRowStripes="1" showColumnStripes="0"/>`,
+	}
+	for _, e := range expectedTable1 {
+		if !strings.Contains(table1, e) {
+			t.Errorf("Expected %s in table 1, got %s", e, table1)
+		}
+	}
+
+	table2 := readZipEntry(t, tmpFile, "xl/tables/table2.xml")
+	if !strings.Contains(table2, `id="2" name="Orders_2" displayName="Orders_2" ref="A1:D3" totalsRowCount="1">`) {
+		t.Errorf("Unexpected table 2: %s", table2)
+	}
+
+	rels := readZipEntry(t, tmpFile, "xl/worksheets/_rels/sheet2.xml.rels")
+	if !strings.Contains(rels, `Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/table" Target="../tables/table2.xml"`) {
+		t.Errorf("Expected table relationship, got %s", rels)
+	}
+
+	contentTypes := readZipEntry(t, tmpFile, "[Content_Types].xml")
+	if !strings.Contains(contentTypes, `<Override PartName="/xl/tables/table2.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.table+xml"/>`) {
+		t.Errorf("Expected table content type, got %s", contentTypes)
+	}
+}
+
+func TestTableValidation(t *testing.T) {
+	tests := []struct {
+		name    string
+		table   *Table
+		headers []interface{}
+	}{
+		{"No headers", &Table{}, nil},
+		{"Cell-like name", &Table{Name: "AB12"}, []interface{}{"A"}},
+		{"Name with space", &Table{Name: "My Table"}, []interface{}{"A"}},
+		{"Unknown function", &Table{TotalsRowFunctions: map[string]string{"A": "median"}}, []interface{}{"A"}},
+		{"Unknown column", &Table{TotalsRowFunctions: map[string]string{"B": "sum"}}, []interface{}{"A"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpFile := "test_table_validation.xlsx"
+			defer os.Remove(tmpFile)
+
+			sink, err := NewFileSink(tmpFile)
+			if err != nil {
+				t.Fatalf("Failed to create sink: %v", err)
+			}
+			defer sink.Close()
+
+			config := DefaultConfig()
+			config.SheetOptions.Table = tt.table
+			writer := NewWriter(sink, config)
+
+			var err2 error
+			if tt.headers != nil {
+				err2 = writer.StartFile(tt.headers)
+			} else {
+				err2 = writer.StartFile()
+			}
+			if err2 == nil {
+				t.Error("Expected error")
+			}
+		})
+	}
+}
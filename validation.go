@@ -0,0 +1,279 @@
+package kolayxlsxstream
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidationType is the kind of data validation rule
+type ValidationType string
+
+// Data validation rule types
+const (
+	ValidationList       ValidationType = "list"
+	ValidationWhole      ValidationType = "whole"
+	ValidationDecimal    ValidationType = "decimal"
+	ValidationDate       ValidationType = "date"
+	ValidationTextLength ValidationType = "textLength"
+	ValidationCustom     ValidationType = "custom"
+)
+
+// ValidationOperator compares a value against a rule's formulas
+type ValidationOperator string
+
+// Data validation operators (default: ValidationBetween)
+const (
+	ValidationBetween            ValidationOperator = "between"
+	ValidationNotBetween         ValidationOperator = "notBetween"
+	ValidationEqual              ValidationOperator = "equal"
+	ValidationNotEqual           ValidationOperator = "notEqual"
+	ValidationGreaterThan        ValidationOperator = "greaterThan"
+	ValidationLessThan           ValidationOperator = "lessThan"
+	ValidationGreaterThanOrEqual ValidationOperator = "greaterThanOrEqual"
+	ValidationLessThanOrEqual    ValidationOperator = "lessThanOrEqual"
+)
+
+// ValidationErrorStyle sets whether invalid input is rejected or only warned about
+type ValidationErrorStyle string
+
+// Data validation error styles (default: ValidationStop)
+const (
+	ValidationStop        ValidationErrorStyle = "stop"
+	ValidationWarning     ValidationErrorStyle = "warning"
+	ValidationInformation ValidationErrorStyle = "information"
+)
+
+// DataValidation is a data validation rule applied to one or more columns.
+// The rule covers the data rows of each sheet (excluding the header row).
+type DataValidation struct {
+	// Columns is the column or column span the rule applies to, e.g. "C" or "C:E"
+	Columns string
+
+	Type     ValidationType
+	Operator ValidationOperator
+
+	// Formula1 and Formula2 are the rule's bounds: numbers, time.Time values
+	// (for date rules) or formula strings. Formula2 is only used by the
+	// between and notBetween operators; custom rules only use Formula1.
+	Formula1 interface{}
+	Formula2 interface{}
+
+	// List holds the allowed values of a list rule, shown as a dropdown.
+	// Alternatively Formula1 can reference a range, e.g. "$H$1:$H$5".
+	List []string
+
+	AllowBlank   bool
+	HideDropDown bool
+
+	InputTitle   string // Up to 32 characters
+	InputMessage string // Up to 255 characters
+	ErrorTitle   string // Up to 32 characters
+	ErrorMessage string // Up to 255 characters
+	ErrorStyle   ValidationErrorStyle
+}
+
+// validate checks the rule's definition
+func (dv *DataValidation) validate() error {
+	if _, _, err := parseColumnSpan(dv.Columns); err != nil {
+		return err
+	}
+
+	switch dv.Type {
+	case ValidationList:
+		if len(dv.List) == 0 && dv.Formula1 == nil {
+			return fmt.Errorf("list validation requires List or Formula1")
+		}
+		if len(dv.List) > 0 {
+			if _, err := listFormula(dv.List); err != nil {
+				return err
+			}
+		}
+	case ValidationWhole, ValidationDecimal, ValidationDate, ValidationTextLength:
+		if dv.Formula1 == nil {
+			return fmt.Errorf("%s validation requires Formula1", dv.Type)
+		}
+		if dv.between() && dv.Formula2 == nil {
+			return fmt.Errorf("%s validation with operator %s requires Formula2", dv.Type, dv.operator())
+		}
+	case ValidationCustom:
+		if dv.Formula1 == nil {
+			return fmt.Errorf("custom validation requires Formula1")
+		}
+	default:
+		return fmt.Errorf("unknown validation type %q", dv.Type)
+	}
+
+	switch dv.operator() {
+	case ValidationBetween, ValidationNotBetween, ValidationEqual, ValidationNotEqual,
+		ValidationGreaterThan, ValidationLessThan, ValidationGreaterThanOrEqual, ValidationLessThanOrEqual:
+	default:
+		return fmt.Errorf("unknown validation operator %q", dv.Operator)
+	}
+
+	switch dv.ErrorStyle {
+	case "", ValidationStop, ValidationWarning, ValidationInformation:
+	default:
+		return fmt.Errorf("unknown validation error style %q", dv.ErrorStyle)
+	}
+
+	for _, f := range []interface{}{dv.Formula1, dv.Formula2} {
+		if _, err := validationFormula(f); err != nil {
+			return err
+		}
+	}
+
+	if utf8.RuneCountInString(dv.InputTitle) > 32 || utf8.RuneCountInString(dv.ErrorTitle) > 32 {
+		return fmt.Errorf("validation titles are limited to 32 characters")
+	}
+	if utf8.RuneCountInString(dv.InputMessage) > 255 || utf8.RuneCountInString(dv.ErrorMessage) > 255 {
+		return fmt.Errorf("validation messages are limited to 255 characters")
+	}
+
+	return nil
+}
+
+// operator returns the rule's operator, defaulting to between
+func (dv *DataValidation) operator() ValidationOperator {
+	if dv.Operator == "" {
+		return ValidationBetween
+	}
+	return dv.Operator
+}
+
+// between reports whether the rule compares against two bounds
+func (dv *DataValidation) between() bool {
+	op := dv.operator()
+	return dv.Type != ValidationList && dv.Type != ValidationCustom &&
+		(op == ValidationBetween || op == ValidationNotBetween)
+}
+
+// parseColumnSpan parses a column or column span such as "C" or "C:E" into
+// zero-based column indexes
+func parseColumnSpan(span string) (first, last int, err error) {
+	if strings.ContainsAny(span, "0123456789") {
+		return 0, 0, fmt.Errorf("invalid column span %q", span)
+	}
+	from, to, isSpan := strings.Cut(span, ":")
+	if !isSpan {
+		to = from
+	}
+	if _, first, err = parseCellReference(from + "1"); err != nil {
+		return 0, 0, fmt.Errorf("invalid column span %q", span)
+	}
+	if _, last, err = parseCellReference(to + "1"); err != nil {
+		return 0, 0, fmt.Errorf("invalid column span %q", span)
+	}
+	return min(first, last), max(first, last), nil
+}
+
+// listFormula builds the quoted, comma separated formula of a list rule
+func listFormula(items []string) (string, error) {
+	for _, item := range items {
+		if strings.Contains(item, ",") {
+			return "", fmt.Errorf("list item %q must not contain a comma", item)
+		}
+	}
+	list := strings.ReplaceAll(strings.Join(items, ","), `"`, `""`)
+	if utf8.RuneCountInString(list) > 255 {
+		return "", fmt.Errorf("list items are limited to 255 characters in total")
+	}
+	return `"` + list + `"`, nil
+}
+
+// validationFormula converts a rule bound to formula text
+func validationFormula(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return strings.TrimPrefix(v, "="), nil
+	case time.Time:
+		return formatFloat(timeToSerial(v), 64), nil
+	default:
+		f, _, err := toNumber(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid validation formula: %w", err)
+		}
+		return formatFloat(f, 64), nil
+	}
+}
+
+// generateXML generates a <dataValidation> element covering the
+// given rows
+func (dv *DataValidation) generateXML(firstRow, lastRow int) string {
+	firstCol, lastCol, _ := parseColumnSpan(dv.Columns)
+
+	var v strings.Builder
+	v.WriteString(fmt.Sprintf(`<dataValidation type="%s"`, dv.Type))
+	if dv.ErrorStyle != "" && dv.ErrorStyle != ValidationStop {
+		v.WriteString(fmt.Sprintf(` errorStyle="%s"`, dv.ErrorStyle))
+	}
+	if dv.Type != ValidationList && dv.Type != ValidationCustom && dv.operator() != ValidationBetween {
+		v.WriteString(fmt.Sprintf(` operator="%s"`, dv.operator()))
+	}
+	if dv.AllowBlank {
+		v.WriteString(` allowBlank="1"`)
+	}
+	if dv.HideDropDown {
+		// showDropDown="1" hides the in-cell dropdown
+		v.WriteString(` showDropDown="1"`)
+	}
+	if dv.InputTitle != "" || dv.InputMessage != "" {
+		v.WriteString(` showInputMessage="1"`)
+	}
+	v.WriteString(` showErrorMessage="1"`)
+	if dv.ErrorTitle != "" {
+		v.WriteString(fmt.Sprintf(` errorTitle="%s"`, escapeXML(dv.ErrorTitle)))
+	}
+	if dv.ErrorMessage != "" {
+		v.WriteString(fmt.Sprintf(` error="%s"`, escapeXML(dv.ErrorMessage)))
+	}
+	if dv.InputTitle != "" {
+		v.WriteString(fmt.Sprintf(` promptTitle="%s"`, escapeXML(dv.InputTitle)))
+	}
+	if dv.InputMessage != "" {
+		v.WriteString(fmt.Sprintf(` prompt="%s"`, escapeXML(dv.InputMessage)))
+	}
+	v.WriteString(fmt.Sprintf(` sqref="%s">`, cellRange{
+		fromRow: firstRow, fromCol: firstCol, toRow: lastRow, toCol: lastCol,
+	}))
+
+	formula1, _ := validationFormula(dv.Formula1)
+	if dv.Type == ValidationList && len(dv.List) > 0 {
+		formula1, _ = listFormula(dv.List)
+	}
+	v.WriteString(fmt.Sprintf(`<formula1>%s</formula1>`, escapeXML(formula1)))
+	if dv.between() {
+		formula2, _ := validationFormula(dv.Formula2)
+		v.WriteString(fmt.Sprintf(`<formula2>%s</formula2>`, escapeXML(formula2)))
+	}
+
+	v.WriteString(`</dataValidation>`)
+	return v.String()
+}
+
+// writeValidations writes the sheet's <dataValidations> element covering
+// its data rows
+func (sw *sheetWriter) writeValidations(validations []DataValidation, dataEnd int) error {
+	firstRow := 0
+	if sw.headersDone {
+		firstRow = 1
+	}
+	if len(validations) == 0 || dataEnd <= firstRow {
+		return nil
+	}
+
+	if _, err := fmt.Fprintf(sw.writer, `<dataValidations count="%d">`, len(validations)); err != nil {
+		return err
+	}
+	for i := range validations {
+		if _, err := io.WriteString(sw.writer, validations[i].generateXML(firstRow, dataEnd-1)); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(sw.writer, "</dataValidations>\n")
+	return err
+}
@@ -0,0 +1,125 @@
+package kolayxlsxstream
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDataValidations(t *testing.T) {
+	tmpFile := "test_validations.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 4
+	config.SheetOptions.Validations = []DataValidation{
+		{
+			Columns:      "C",
+			Type:         ValidationList,
+			List:         []string{"Open", "In Progress", `Say "Done"`},
+			AllowBlank:   true,
+			InputTitle:   "Status",
+			InputMessage: "Pick a status",
+			ErrorTitle:   "Invalid status",
+			ErrorMessage: "Choose a value from the list",
+		},
+		{
+			Columns:  "B:B",
+			Type:     ValidationWhole,
+			Formula1: 1,
+			Formula2: 100,
+		},
+		{
+			Columns:    "D",
+			Type:       ValidationDate,
+			Operator:   ValidationGreaterThanOrEqual,
+			Formula1:   time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+			ErrorStyle: ValidationWarning,
+		},
+		{
+			Columns:  "E:F",
+			Type:     ValidationCustom,
+			Formula1: "=ISNUMBER(E2)",
+		},
+	}
+
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile([]interface{}{"ID", "Qty", "Status", "Due", "X", "Y"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	for i := 1; i <= 5; i++ {
+		if err := writer.WriteRow([]interface{}{i, 1, "Open"}); err != nil {
+			t.Fatalf("Failed to write row %d: %v", i, err)
+		}
+	}
+
+	if _, err := writer.FinishFile(); err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := []string{
+		`</sheetData>
+<dataValidations count="4">`,
+		`<dataValidation type="list" allowBlank="1" showInputMessage="1" showErrorMessage="1" errorTitle="Invalid status" error="Choose a value from the list" promptTitle="Status" prompt="Pick a status" sqref="C2:C4"><formula1>&#34;Open,In Progress,Say &#34;&#34;Done&#34;&#34;&#34;</formula1></dataValidation>`,
+		`<dataValidation type="whole" showErrorMessage="1" sqref="B2:B4"><formula1>1</formula1><formula2>100</formula2></dataValidation>`,
+		`<dataValidation type="date" errorStyle="warning" operator="greaterThanOrEqual" showErrorMessage="1" sqref="D2:D4"><formula1>45292</formula1></dataValidation>`,
+		`<dataValidation type="custom" showErrorMessage="1" sqref="E2:F4"><formula1>ISNUMBER(E2)</formula1></dataValidation>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheet1, e) {
+			t.Errorf("Expected %s in sheet 1, got %s", e, sheet1)
+		}
+	}
+
+	// The rollover sheet has no header row, so validations start at row 1
+	sheet2 := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	if !strings.Contains(sheet2, `sqref="C1:C2"`) {
+		t.Errorf("Expected validation covering rows 1-2 in sheet 2, got %s", sheet2)
+	}
+}
+
+func TestDataValidationErrors(t *testing.T) {
+	invalid := []DataValidation{
+		{Columns: "", Type: ValidationList, List: []string{"a"}},
+		{Columns: "A", Type: "regex", Formula1: "x"},
+		{Columns: "A", Type: ValidationList},
+		{Columns: "A", Type: ValidationList, List: []string{"a,b"}},
+		{Columns: "A", Type: ValidationList, List: []string{strings.Repeat("x", 256)}},
+		{Columns: "A", Type: ValidationWhole, Formula1: 1},
+		{Columns: "A", Type: ValidationWhole, Operator: "around", Formula1: 1},
+		{Columns: "A", Type: ValidationDecimal, Operator: ValidationLessThan, Formula1: struct{}{}},
+		{Columns: "A", Type: ValidationCustom, Formula1: "A1>0", ErrorStyle: "fatal"},
+		{Columns: "A", Type: ValidationCustom, Formula1: "A1>0", InputTitle: strings.Repeat("t", 33)},
+	}
+
+	for i, dv := range invalid {
+		if err := dv.validate(); err == nil {
+			t.Errorf("Expected error for validation %d: %+v", i, dv)
+		}
+	}
+
+	// Invalid rules are rejected before anything is written
+	tmpFile := "test_validation_errors.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+	defer sink.Close()
+
+	config := DefaultConfig()
+	config.SheetOptions.Validations = invalid[:1]
+	if err := NewWriter(sink, config).StartFile(); err == nil {
+		t.Error("Expected StartFile to reject invalid validation")
+	}
+}
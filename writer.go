@@ -5,6 +5,7 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"path"
 	"time"
 )
 
@@ -17,22 +18,77 @@ type Writer struct {
 	started   bool
 	finished  bool
 
-	currentSheet      int
-	currentSheetRows  int
-	currentSheetIndex int
-	sheetWriters      []*sheetWriter
-	totalRows         int64
-	startTime         time.Time
-	bytesWritten      int64
+	currentSheet     int
+	currentSheetRows int
+	current          *sheetWriter   // Sheet receiving the Writer's rows
+	mainSheetCount   int            // Sheets created by StartFile and rollover
+	sheetWriters     []*sheetWriter // All sheets in workbook order
+	handles          []*SheetHandle // Named sheets, spooled until FinishFile
+	totalRows        int64
+	startTime        time.Time
+	bytesWritten     int64
+
+	styles           *styleSheet       // Cell and differential formats for xl/styles.xml
+	cfDxfIDs         []int             // Differential format of each conditional format
+	headers          []interface{}     // Header row, repeated on every sheet when a table is configured
+	tableColumns     []string          // Table column names derived from the header row
+	tableCount       int               // Number of table parts written
+	commentsCount    int               // Number of comments parts written
+	drawingCount     int               // Number of drawing parts written
+	imageCount       int               // Number of media parts written
+	chartCount       int               // Number of chart parts written
+	pendingDrawings  []*drawing        // Drawings with charts, written by FinishFile
+	definedNames     []definedName     // Names for the workbook's <definedNames> block
+	sheetPassword    *passwordHash     // Hashed SheetOptions.Protection password
+	workbookPassword *passwordHash     // Hashed WorkbookProtection password
+	nextShapeID      int               // Next VML shape ID, unique across the workbook
+	compressors      *compressorPool   // Block compressor in pipelined mode, nil otherwise
+	contentOverrides []contentOverride // Content types of parts created while streaming
+	contentDefaults  map[string]string // Content types by file extension, e.g. for images
+}
+
+// contentOverride is an [Content_Types].xml override for a single part
+type contentOverride struct {
+	partName    string
+	contentType string
 }
 
 // sheetWriter handles writing to a single sheet
 type sheetWriter struct {
 	writer      io.Writer
-	rowCount    int
+	config      *Config
+	styles      *styleSheet
+	name        string
+	spool       *sheetSpool // Compressed XML of a named sheet; nil when writing to the archive
+	rowCount    int         // Rows written or skipped; the next row's zero-based index
+	lastRow     int         // 1-based number of the last row written, 0 if none
 	sheetIndex  int
 	headersDone bool
 	closed      bool
+
+	buf     []byte   // Row XML buffer, reused for every row
+	columns []string // Column letters, computed once per sheet
+
+	merges         []cellRange // Merged ranges, sorted by first row
+	mergeMaxHeight int         // Largest row span of any merged range
+
+	hyperlinks *spillBuffer       // <hyperlink> elements, written after </sheetData>
+	rowLinks   []pendingHyperlink // Links of the row being generated
+	rels       *spillBuffer       // Relationship elements for the sheet's .rels part
+	relCount   int
+
+	comments         *spillBuffer        // <comment> elements for the comments part
+	commentShapes    *spillBuffer        // VML shapes displaying the comments
+	commentAuthors   map[string]int      // Author name to authorId
+	commentAuthorIDs []string            // Authors in authorId order
+	commentCells     map[uint64]struct{} // Cells with a comment, by cellKey
+	firstShapeID     int                 // First VML shape ID used by the sheet's comments
+	lastShapeID      int                 // Last VML shape ID used by the sheet's comments
+
+	images    []*sheetImage // Images for the sheet's drawing part
+	imageData *spillBuffer  // Data of the sheet's images, one after another
+	charts    []*sheetChart // Charts for the sheet's drawing part
+	dataEnd   int           // Last data row, recorded when the sheet is closed
 }
 
 // NewWriter creates a new XLSX writer with the given sink and optional config
@@ -46,6 +102,7 @@ func NewWriter(sink Sink, config ...*Config) *Writer {
 		sink:         sink,
 		config:       cfg,
 		sheetWriters: make([]*sheetWriter, 0),
+		styles:       newStyleSheet(),
 	}
 }
 
@@ -55,84 +112,254 @@ func (w *Writer) StartFile(headers ...[]interface{}) error {
 		return fmt.Errorf("file already started")
 	}
 
+	if err := w.config.SheetOptions.validate(); err != nil {
+		return err
+	}
+	if err := w.config.Properties.validate(); err != nil {
+		return fmt.Errorf("invalid document properties: %w", err)
+	}
+	if err := validateParallelConfig(w.config); err != nil {
+		return err
+	}
+
+	// Register the formats applied by conditional formatting
+	for _, cf := range w.config.SheetOptions.ConditionalFormats {
+		dxfID := 0
+		if cf.Format != nil {
+			var err error
+			if dxfID, err = w.styles.addDifferentialStyle(*cf.Format); err != nil {
+				return fmt.Errorf("invalid conditional format style: %w", err)
+			}
+		}
+		w.cfDxfIDs = append(w.cfDxfIDs, dxfID)
+	}
+
+	if table := w.config.SheetOptions.Table; table != nil {
+		var headerRow []interface{}
+		if len(headers) > 0 {
+			headerRow = headers[0]
+		}
+		columns, err := table.validate(headerRow)
+		if err != nil {
+			return err
+		}
+		if w.sheetCapacity() < 2 {
+			return fmt.Errorf("max rows per sheet is too small for a table")
+		}
+
+		// Write the normalized column names so header cells match the table
+		w.tableColumns = columns
+		w.headers = make([]interface{}, len(columns))
+		for i, column := range columns {
+			w.headers[i] = column
+		}
+	} else if len(headers) > 0 && len(headers[0]) > 0 {
+		w.headers = headers[0]
+	}
+
+	// Hash protection passwords once; hashing is deliberately slow
+	if p := w.config.SheetOptions.Protection; p != nil && p.Password != "" {
+		hash, err := hashPassword(p.Password)
+		if err != nil {
+			return err
+		}
+		w.sheetPassword = &hash
+	}
+	if p := w.config.WorkbookProtection; p != nil && p.Password != "" {
+		hash, err := hashPassword(p.Password)
+		if err != nil {
+			return err
+		}
+		w.workbookPassword = &hash
+	}
+
 	w.started = true
 	w.startTime = time.Now()
 	w.zipWriter = zip.NewWriter(w.sink)
 
-	// Set compression level
-	w.zipWriter.RegisterCompressor(zip.Deflate, func(out io.Writer) (io.WriteCloser, error) {
-		return newFlateWriter(out, w.config.CompressionLevel)
-	})
+	// Set compression level, compressing blocks concurrently in pipelined mode
+	if w.config.ParallelWorkers > 1 {
+		w.compressors = newCompressorPool(w.config)
+	}
+	w.zipWriter.RegisterCompressor(zip.Deflate, w.newCompressor)
 
 	// Write _rels/.rels
-	if err := w.writeZipFile("_rels/.rels", []byte(relsXML)); err != nil {
+	if err := w.writeZipFile("_rels/.rels", []byte(w.config.Properties.generateRelsXML())); err != nil {
 		return fmt.Errorf("failed to write _rels/.rels: %w", err)
 	}
 
-	// Write xl/styles.xml
-	if err := w.writeZipFile("xl/styles.xml", []byte(stylesXML)); err != nil {
-		return fmt.Errorf("failed to write styles.xml: %w", err)
-	}
-
 	// Start the first sheet
 	if err := w.startNewSheet(); err != nil {
 		return err
 	}
 
 	// Write headers if provided
-	if len(headers) > 0 && len(headers[0]) > 0 {
-		if err := w.WriteRow(headers[0]); err != nil {
-			return fmt.Errorf("failed to write headers: %w", err)
+	if len(w.headers) > 0 {
+		if err := w.writeHeaderRow(w.current); err != nil {
+			return err
 		}
-		w.sheetWriters[0].headersDone = true
-		w.totalRows-- // Don't count header row in statistics
 	}
 
 	return nil
 }
 
+// writeHeaderRow writes the header row to a sheet without counting it in
+// statistics
+func (w *Writer) writeHeaderRow(sw *sheetWriter) error {
+	if err := sw.writeRow(w.headers, nil); err != nil {
+		return fmt.Errorf("failed to write headers: %w", err)
+	}
+	sw.headersDone = true
+	return nil
+}
+
 // WriteRow writes a single row to the current sheet
 func (w *Writer) WriteRow(values []interface{}) error {
-	if !w.started {
-		return fmt.Errorf("file not started, call StartFile first")
+	return w.writeRow(values, nil)
+}
+
+// writeRow writes a row to the current sheet, rolling over to a new sheet
+// when it is full
+func (w *Writer) writeRow(values []interface{}, opts *RowOptions) error {
+	sw, err := w.rowSheet()
+	if err != nil {
+		return err
 	}
-	if w.finished {
-		return fmt.Errorf("file already finished")
+
+	if err := sw.writeRow(values, opts); err != nil {
+		return err
 	}
+	w.totalRows++
 
-	// Check if we need to start a new sheet
-	currentWriter := w.sheetWriters[w.currentSheetIndex]
-	if currentWriter.rowCount >= w.config.MaxRowsPerSheet {
+	return nil
+}
+
+// rowSheet returns the sheet that receives the next row, starting a new
+// sheet when the current one is full
+func (w *Writer) rowSheet() (*sheetWriter, error) {
+	if err := w.checkWritable(); err != nil {
+		return nil, err
+	}
+
+	if w.current.rowCount >= w.sheetCapacity() {
 		if err := w.startNewSheet(); err != nil {
-			return err
+			return nil, err
 		}
-		currentWriter = w.sheetWriters[w.currentSheetIndex]
 	}
+	return w.current, nil
+}
 
-	// Generate and write the row XML
-	rowXML := generateRow(currentWriter.rowCount, values)
-	if _, err := currentWriter.writer.Write([]byte(rowXML + "\n")); err != nil {
+// sheetCapacity returns how many rows can be written to a sheet before
+// rolling over, keeping room for rows added when the sheet is closed
+func (w *Writer) sheetCapacity() int {
+	if table := w.config.SheetOptions.Table; table != nil && table.TotalsRow {
+		return w.config.MaxRowsPerSheet - 1
+	}
+	return w.config.MaxRowsPerSheet
+}
+
+// writeRow generates and writes the XML for the sheet's next row, reusing
+// the sheet's row buffer
+func (sw *sheetWriter) writeRow(values []interface{}, opts *RowOptions) error {
+	sw.growColumns(len(values))
+	buf, err := sw.appendRow(sw.buf[:0], sw.rowCount, values, opts)
+	sw.buf = buf
+	if err != nil {
+		sw.rowLinks = sw.rowLinks[:0]
+		return fmt.Errorf("failed to generate row: %w", err)
+	}
+	return sw.writeRowXML(buf)
+}
+
+// writeRowXML writes a generated row, records its hyperlinks and advances
+// to the next row
+func (sw *sheetWriter) writeRowXML(rowXML []byte) error {
+	if _, err := sw.writer.Write(rowXML); err != nil {
+		sw.rowLinks = sw.rowLinks[:0]
 		return fmt.Errorf("failed to write row: %w", err)
 	}
 
-	currentWriter.rowCount++
+	sw.rowCount++
+	sw.lastRow = sw.rowCount
+	return sw.recordHyperlinks()
+}
+
+// SkipRows leaves n blank rows before the next row. Skipping past the end of
+// the sheet makes the next row start a new sheet; the skip does not carry over.
+func (w *Writer) SkipRows(n int) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+	if n < 0 {
+		return fmt.Errorf("cannot skip a negative number of rows: %d", n)
+	}
+
+	sw := w.current
+	sw.rowCount = min(sw.rowCount+n, w.sheetCapacity())
+	return nil
+}
+
+// WriteRowAt writes a row at a 1-based row number of the current sheet,
+// leaving the rows in between blank. Row numbers must increase: rowNumber
+// has to be after every row written or skipped so far, and within the sheet.
+func (w *Writer) WriteRowAt(rowNumber int, values []interface{}) error {
+	if err := w.checkWritable(); err != nil {
+		return err
+	}
+
+	sw := w.current
+	if rowNumber <= sw.rowCount {
+		return fmt.Errorf("row %d must come after row %d", rowNumber, sw.rowCount)
+	}
+	if rowNumber > w.sheetCapacity() {
+		return fmt.Errorf("row %d exceeds the sheet limit of %d rows", rowNumber, w.sheetCapacity())
+	}
+
+	sw.rowCount = rowNumber - 1
+	if err := sw.writeRow(values, nil); err != nil {
+		return err
+	}
 	w.totalRows++
 
 	return nil
 }
 
-// WriteRows writes multiple rows to the current sheet
+// WriteRows writes multiple rows to the current sheet, rolling over to new
+// sheets as they fill up. In pipelined mode (Config.ParallelWorkers) the rows
+// are encoded concurrently and written in order.
 func (w *Writer) WriteRows(rows [][]interface{}) error {
-	for _, row := range rows {
-		if err := w.WriteRow(row); err != nil {
+	for len(rows) > 0 {
+		sw, err := w.rowSheet()
+		if err != nil {
+			return err
+		}
+
+		n := min(len(rows), w.sheetCapacity()-sw.rowCount)
+		written, err := sw.writeRows(rows[:n], w.config.ParallelWorkers)
+		w.totalRows += int64(written)
+		if err != nil {
 			return err
 		}
+		rows = rows[n:]
 	}
 	return nil
 }
 
-// FinishFile finalizes the XLSX file and returns statistics
-func (w *Writer) FinishFile() (*Stats, error) {
+// checkWritable returns an error unless the file is started and not finished
+func (w *Writer) checkWritable() error {
+	if !w.started {
+		return fmt.Errorf("file not started, call StartFile first")
+	}
+	if w.finished {
+		return fmt.Errorf("file already finished")
+	}
+	return nil
+}
+
+// FinishFile finalizes the XLSX file and returns statistics. If it fails,
+// the temporary files of every sheet are removed; the sink is left for the
+// caller to close or abort.
+func (w *Writer) FinishFile() (stats *Stats, err error) {
 	if !w.started {
 		return nil, fmt.Errorf("file not started")
 	}
@@ -141,20 +368,49 @@ func (w *Writer) FinishFile() (*Stats, error) {
 	}
 
 	w.finished = true
+	defer func() {
+		if err != nil {
+			w.releaseSheets()
+		}
+	}()
 
-	// Close the last sheet (others were closed when new sheets were created)
-	if len(w.sheetWriters) > 0 {
-		lastSheet := w.sheetWriters[len(w.sheetWriters)-1]
-		if !lastSheet.closed {
-			if _, err := lastSheet.writer.Write([]byte(worksheetFooter)); err != nil {
-				return nil, fmt.Errorf("failed to write worksheet footer: %w", err)
-			}
-			lastSheet.closed = true
+	// Close the current sheet (earlier ones were closed on rollover), then
+	// copy the spooled named sheets into the archive
+	if err := w.closeSheet(w.current); err != nil {
+		return nil, err
+	}
+	for _, h := range w.handles {
+		if err := w.closeSheet(h.sw); err != nil {
+			return nil, fmt.Errorf("failed to close sheet %q: %w", h.sw.name, err)
 		}
+		w.totalRows += int64(h.rows)
+	}
+
+	// Charts are written once the rows of every sheet are known
+	if err := w.writePendingDrawings(); err != nil {
+		return nil, fmt.Errorf("failed to write charts: %w", err)
+	}
+
+	// Write xl/styles.xml (styles may be added while streaming)
+	if err := w.writeZipFile("xl/styles.xml", []byte(w.styles.generateXML())); err != nil {
+		return nil, fmt.Errorf("failed to write styles.xml: %w", err)
+	}
+
+	// Write the document properties (app.xml lists the final sheet names)
+	if err := w.writeParts(w.documentPropertyParts()); err != nil {
+		return nil, fmt.Errorf("failed to write document properties: %w", err)
 	}
 
 	// Write xl/workbook.xml
-	workbookXML := generateWorkbookXML(len(w.sheetWriters), w.config.SheetNamePrefix)
+	definedNamesXML, err := w.generateDefinedNamesXML()
+	if err != nil {
+		return nil, err
+	}
+	var protectionXML string
+	if p := w.config.WorkbookProtection; p != nil {
+		protectionXML = p.generateXML(w.workbookPassword)
+	}
+	workbookXML := generateWorkbookXML(w.sheetNames(), protectionXML, definedNamesXML)
 	if err := w.writeZipFile("xl/workbook.xml", []byte(workbookXML)); err != nil {
 		return nil, fmt.Errorf("failed to write workbook.xml: %w", err)
 	}
@@ -166,7 +422,7 @@ func (w *Writer) FinishFile() (*Stats, error) {
 	}
 
 	// Write [Content_Types].xml
-	contentTypesXML := generateContentTypesXML(len(w.sheetWriters))
+	contentTypesXML := generateContentTypesXML(len(w.sheetWriters), w.contentDefaults, w.contentOverrides)
 	if err := w.writeZipFile("[Content_Types].xml", []byte(contentTypesXML)); err != nil {
 		return nil, fmt.Errorf("failed to write [Content_Types].xml: %w", err)
 	}
@@ -183,7 +439,7 @@ func (w *Writer) FinishFile() (*Stats, error) {
 
 	// Calculate statistics
 	duration := time.Since(w.startTime).Seconds()
-	stats := &Stats{
+	stats = &Stats{
 		TotalRows:   w.totalRows,
 		TotalSheets: len(w.sheetWriters),
 		Duration:    duration,
@@ -196,6 +452,29 @@ func (w *Writer) FinishFile() (*Stats, error) {
 	return stats, nil
 }
 
+// Abort abandons a file that will not be finished, removing the temporary
+// files of every sheet. The sink is not closed: close it, or abort it like
+// S3Sink.Abort, as the destination requires. The Writer cannot be used
+// afterwards.
+func (w *Writer) Abort() {
+	if w.finished {
+		return
+	}
+	w.finished = true
+	w.releaseSheets()
+}
+
+// releaseSheets frees the sheets that are still open, along with their
+// temporary files
+func (w *Writer) releaseSheets() {
+	if w.current != nil {
+		w.current.release()
+	}
+	for _, h := range w.handles {
+		h.sw.release()
+	}
+}
+
 // SetCompressionLevel sets the ZIP compression level (0-9)
 func (w *Writer) SetCompressionLevel(level int) error {
 	if w.started {
@@ -235,13 +514,9 @@ func (w *Writer) SetMaxRowsPerSheet(rows int) error {
 // startNewSheet creates a new worksheet in the ZIP
 func (w *Writer) startNewSheet() error {
 	// If there's a previous sheet, close it by writing footer
-	if len(w.sheetWriters) > 0 {
-		prevSheet := w.sheetWriters[len(w.sheetWriters)-1]
-		if !prevSheet.closed {
-			if _, err := prevSheet.writer.Write([]byte(worksheetFooter)); err != nil {
-				return fmt.Errorf("failed to close previous sheet: %w", err)
-			}
-			prevSheet.closed = true
+	if w.current != nil {
+		if err := w.closeSheet(w.current); err != nil {
+			return fmt.Errorf("failed to close previous sheet: %w", err)
 		}
 	}
 
@@ -255,23 +530,304 @@ func (w *Writer) startNewSheet() error {
 	}
 
 	// Write worksheet header
-	if _, err := writer.Write([]byte(worksheetHeader + "\n")); err != nil {
+	if _, err := io.WriteString(writer, w.config.SheetOptions.generateWorksheetHeader()); err != nil {
 		return fmt.Errorf("failed to write worksheet header: %w", err)
 	}
 
 	// Create sheet writer
+	w.mainSheetCount++
 	sw := &sheetWriter{
 		writer:     writer,
+		config:     w.config,
+		styles:     w.styles,
 		rowCount:   0,
 		sheetIndex: sheetNum - 1,
+		name:       fmt.Sprintf("%s%d", w.config.SheetNamePrefix, w.mainSheetCount),
 	}
 
 	w.sheetWriters = append(w.sheetWriters, sw)
-	w.currentSheetIndex = len(w.sheetWriters) - 1
+	w.current = sw
+
+	// Tables need their header row on every sheet
+	if w.tableColumns != nil && w.mainSheetCount > 1 {
+		if err := w.writeHeaderRow(sw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// newCompressor returns the deflate compressor for a part of the archive
+func (w *Writer) newCompressor(out io.Writer) (io.WriteCloser, error) {
+	if w.compressors != nil {
+		return w.compressors.newWriter(out), nil
+	}
+	return newFlateWriter(out, w.config.CompressionLevel)
+}
+
+// sheetPart is a part referenced by a sheet, written to the ZIP once the
+// sheet's own entry is complete
+type sheetPart struct {
+	name        string
+	contentType string
+	write       func(io.Writer) error
+}
+
+// closeSheet writes the elements collected while streaming the sheet and the
+// worksheet footer, followed by the sheet's relationships and related parts
+func (w *Writer) closeSheet(sw *sheetWriter) error {
+	if sw.closed {
+		return nil
+	}
+	sw.closed = true
+	defer sw.release()
+
+	var parts []sheetPart
+
+	// Rows added when closing (such as a table's totals row) are not data rows
+	dataEnd := sw.rowCount
+	sw.dataEnd = dataEnd
+
+	var tableID int
+	var tableName string
+	if w.tableColumns != nil && sw.spool == nil {
+		var err error
+		if tableID, tableName, err = w.writeTable(sw); err != nil {
+			return err
+		}
+	}
+
+	if _, err := io.WriteString(sw.writer, sheetDataFooter); err != nil {
+		return fmt.Errorf("failed to write worksheet footer: %w", err)
+	}
+
+	// The remaining elements follow the order required by the worksheet schema
+	if p := w.config.SheetOptions.Protection; p != nil {
+		if _, err := io.WriteString(sw.writer, p.generateXML(w.sheetPassword)); err != nil {
+			return fmt.Errorf("failed to write sheet protection: %w", err)
+		}
+	}
+
+	if err := sw.writeMerges(); err != nil {
+		return fmt.Errorf("failed to write merged cells: %w", err)
+	}
+
+	if err := sw.writeConditionalFormats(w.config.SheetOptions.ConditionalFormats, w.cfDxfIDs, dataEnd); err != nil {
+		return fmt.Errorf("failed to write conditional formatting: %w", err)
+	}
+
+	if err := sw.writeValidations(w.config.SheetOptions.Validations, dataEnd); err != nil {
+		return fmt.Errorf("failed to write data validations: %w", err)
+	}
+
+	if sw.hyperlinks != nil {
+		if err := writeWrapped(sw.writer, "<hyperlinks>", sw.hyperlinks, "</hyperlinks>\n"); err != nil {
+			return fmt.Errorf("failed to write hyperlinks: %w", err)
+		}
+	}
+
+	if pageSetup := w.config.SheetOptions.PageSetup; pageSetup != nil {
+		if _, err := io.WriteString(sw.writer, pageSetup.generateXML()); err != nil {
+			return fmt.Errorf("failed to write page setup: %w", err)
+		}
+		if pageSetup.PrintArea != "" {
+			if err := w.addDefinedName(w.printArea(sw, pageSetup.PrintArea)); err != nil {
+				return fmt.Errorf("failed to add print area: %w", err)
+			}
+		}
+	}
+
+	if len(sw.images) > 0 || len(sw.charts) > 0 {
+		drawingParts, err := w.writeDrawing(sw)
+		if err != nil {
+			return fmt.Errorf("failed to write drawing: %w", err)
+		}
+		parts = append(parts, drawingParts...)
+	}
+
+	if sw.comments != nil {
+		commentParts, err := w.writeLegacyDrawing(sw)
+		if err != nil {
+			return fmt.Errorf("failed to write comments: %w", err)
+		}
+		parts = append(parts, commentParts...)
+	}
+
+	if tableID > 0 {
+		tablePart := fmt.Sprintf("xl/tables/table%d.xml", tableID)
+		relID, err := sw.addRelationship(relTypeTable, "../tables/"+path.Base(tablePart), false)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(sw.writer, `<tableParts count="1"><tablePart r:id="%s"/></tableParts>
+`, relID); err != nil {
+			return fmt.Errorf("failed to write table parts: %w", err)
+		}
+		tableXML := w.generateTableXML(sw, tableID, tableName)
+		parts = append(parts, sheetPart{
+			name:        tablePart,
+			contentType: contentTypeTable,
+			write: func(pw io.Writer) error {
+				_, err := io.WriteString(pw, tableXML)
+				return err
+			},
+		})
+	}
 
+	if _, err := io.WriteString(sw.writer, worksheetFooter); err != nil {
+		return fmt.Errorf("failed to write worksheet footer: %w", err)
+	}
+
+	if sw.spool != nil {
+		if err := sw.spool.copyTo(w.zipWriter, fmt.Sprintf("xl/worksheets/sheet%d.xml", sw.sheetIndex+1)); err != nil {
+			return err
+		}
+	}
+
+	if sw.rels != nil {
+		relsName := fmt.Sprintf("xl/worksheets/_rels/sheet%d.xml.rels", sw.sheetIndex+1)
+		relsWriter, err := w.zipWriter.Create(relsName)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", relsName, err)
+		}
+		if err := writeWrapped(relsWriter, sheetRelsXMLHeader, sw.rels, sheetRelsXMLFooter); err != nil {
+			return fmt.Errorf("failed to write %s: %w", relsName, err)
+		}
+	}
+
+	return w.writeParts(parts)
+}
+
+// documentPropertyParts returns the docProps parts of the package
+func (w *Writer) documentPropertyParts() []sheetPart {
+	props := &w.config.Properties
+	parts := []sheetPart{
+		xmlPart("docProps/core.xml", contentTypeCoreProperties, props.generateCoreXML(w.startTime)),
+		xmlPart("docProps/app.xml", contentTypeExtendedProperties, props.generateAppXML(w.sheetNames())),
+	}
+	if len(props.Custom) > 0 {
+		parts = append(parts, xmlPart("docProps/custom.xml", contentTypeCustomProperties, props.generateCustomXML()))
+	}
+	return parts
+}
+
+// sheetName returns the name of a sheet by its 1-based number
+func (w *Writer) sheetName(sheet int) string {
+	return w.sheetWriters[sheet-1].name
+}
+
+// sheetNames returns the names of all sheets created so far
+func (w *Writer) sheetNames() []string {
+	names := make([]string, len(w.sheetWriters))
+	for i := range names {
+		names[i] = w.sheetName(i + 1)
+	}
+	return names
+}
+
+// xmlPart returns a part with generated XML content
+func xmlPart(name, contentType, xml string) sheetPart {
+	return sheetPart{
+		name:        name,
+		contentType: contentType,
+		write: func(pw io.Writer) error {
+			_, err := io.WriteString(pw, xml)
+			return err
+		},
+	}
+}
+
+// writeParts writes parts to the archive and registers their content types
+func (w *Writer) writeParts(parts []sheetPart) error {
+	for _, part := range parts {
+		partWriter, err := w.zipWriter.Create(part.name)
+		if err != nil {
+			return fmt.Errorf("failed to create %s: %w", part.name, err)
+		}
+		if err := part.write(partWriter); err != nil {
+			return fmt.Errorf("failed to write %s: %w", part.name, err)
+		}
+		if part.contentType != "" {
+			w.addContentOverride(part.name, part.contentType)
+		}
+	}
 	return nil
 }
 
+// addContentOverride registers the content type of a part created while
+// streaming
+func (w *Writer) addContentOverride(partName, contentType string) {
+	w.contentOverrides = append(w.contentOverrides, contentOverride{
+		partName:    "/" + partName,
+		contentType: contentType,
+	})
+}
+
+// addContentDefault registers the content type of parts with a file extension
+func (w *Writer) addContentDefault(extension, contentType string) {
+	if w.contentDefaults == nil {
+		w.contentDefaults = make(map[string]string)
+	}
+	w.contentDefaults[extension] = contentType
+}
+
+// addRelationship records a relationship for the sheet's .rels part and
+// returns its ID
+func (sw *sheetWriter) addRelationship(relType, target string, external bool) (string, error) {
+	if sw.rels == nil {
+		sw.rels = newSpillBuffer(sw.config.SpillThreshold, sw.config.TempDir)
+	}
+
+	sw.relCount++
+	id := fmt.Sprintf("rId%d", sw.relCount)
+
+	targetMode := ""
+	if external {
+		targetMode = ` TargetMode="External"`
+	}
+	rel := fmt.Sprintf(`<Relationship Id="%s" Type="%s" Target="%s"%s/>
+`, id, relType, escapeXML(target), targetMode)
+	if _, err := sw.rels.WriteString(rel); err != nil {
+		return "", fmt.Errorf("failed to record relationship: %w", err)
+	}
+
+	return id, nil
+}
+
+// release frees the sheet's collected elements and their temporary files
+func (sw *sheetWriter) release() {
+	for _, sb := range []*spillBuffer{sw.hyperlinks, sw.rels, sw.comments, sw.commentShapes, sw.imageData} {
+		if sb != nil {
+			sb.Close()
+		}
+	}
+	sw.hyperlinks = nil
+	sw.rels = nil
+	if sw.spool != nil {
+		sw.spool.Close()
+		sw.spool = nil
+	}
+	sw.comments = nil
+	sw.commentShapes = nil
+	sw.commentCells = nil
+	sw.images = nil
+	sw.imageData = nil
+	sw.charts = nil
+}
+
+// writeWrapped copies a spill buffer to w between a header and a footer
+func writeWrapped(w io.Writer, header string, sb *spillBuffer, footer string) error {
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+	if _, err := sb.WriteTo(w); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, footer)
+	return err
+}
+
 // writeZipFile writes a complete file to the ZIP archive
 func (w *Writer) writeZipFile(name string, data []byte) error {
 	writer, err := w.zipWriter.Create(name)
@@ -2,11 +2,72 @@ package kolayxlsxstream
 
 import (
 	"archive/zip"
+	"bytes"
+	"errors"
 	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"math"
 	"os"
+	"strings"
 	"testing"
 )
 
+// newTestSheetWriter returns a sheet writer for testing row and cell generation
+func newTestSheetWriter(config *Config) *sheetWriter {
+	if config == nil {
+		config = DefaultConfig()
+	}
+	return &sheetWriter{config: config, styles: newStyleSheet()}
+}
+
+// cellXML returns the XML of a single value at a cell reference such as "A1"
+func cellXML(sw *sheetWriter, ref string, value interface{}) (string, error) {
+	row, col, err := parseCellReference(ref)
+	if err != nil {
+		return "", err
+	}
+	cell, err := sw.appendCell(nil, sw.cellRef(row, col), 0, value)
+	return string(cell), err
+}
+
+// generateRowXML returns the XML of a row without its trailing newline
+func generateRowXML(sw *sheetWriter, rowIndex int, values []interface{}) (string, error) {
+	row, err := sw.appendRow(nil, rowIndex, values, nil)
+	return strings.TrimSuffix(string(row), "\n"), err
+}
+
+// readZipEntry returns the contents of a single entry of the XLSX file at path
+func readZipEntry(t *testing.T, path, name string) string {
+	t.Helper()
+
+	zipReader, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatalf("Failed to open output as ZIP: %v", err)
+	}
+	defer zipReader.Close()
+
+	for _, f := range zipReader.File {
+		if f.Name != name {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatalf("Failed to open %s: %v", name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		return string(data)
+	}
+
+	t.Fatalf("Entry %s not found in ZIP", name)
+	return ""
+}
+
 func TestBasicWrite(t *testing.T) {
 	// Create temporary file
 	tmpFile := "test_output.xlsx"
@@ -457,6 +518,98 @@ func TestEmptyFile(t *testing.T) {
 	}
 }
 
+func TestFormatFloat(t *testing.T) {
+	tests := []struct {
+		value    float64
+		bitSize  int
+		expected string
+	}{
+		{0, 64, "0"},
+		{math.Copysign(0, -1), 64, "0"},
+		{3.14159, 64, "3.14159"},
+		{-2.5, 64, "-2.5"},
+		{0.30000000000000004, 64, "0.30000000000000004"},
+		{123456789012345, 64, "123456789012345"},
+		{1e20, 64, "100000000000000000000"},
+		{1e21, 64, "1E+21"},
+		{1.7976931348623157e+308, 64, "1.7976931348623157E+308"},
+		{0.000001, 64, "0.000001"},
+		{0.0000001, 64, "1E-07"},
+		{float64(float32(0.1)), 32, "0.1"},
+	}
+
+	for _, tt := range tests {
+		if got := formatFloat(tt.value, tt.bitSize); got != tt.expected {
+			t.Errorf("formatFloat(%v, %d) = %q, expected %q", tt.value, tt.bitSize, got, tt.expected)
+		}
+	}
+}
+
+func TestNonFiniteFloatPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   NonFiniteFloatPolicy
+		expected []string
+	}{
+		{"Empty", NonFiniteEmpty, []string{`<c r="A1"/>`, `<c r="B1"/>`, `<c r="C1"/>`}},
+		{"NumError", NonFiniteNumError, []string{`<c r="A1" t="e"><v>#NUM!</v></c>`, `<c r="C1" t="e"><v>#NUM!</v></c>`}},
+		{"String", NonFiniteString, []string{`<t>NaN</t>`, `<t>+Inf</t>`, `<t>-Inf</t>`}},
+	}
+
+	row := []interface{}{math.NaN(), math.Inf(1), float32(math.Inf(-1))}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.NonFiniteFloatPolicy = tt.policy
+			rowXML, err := generateRowXML(newTestSheetWriter(config), 0, row)
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			for _, expected := range tt.expected {
+				if !strings.Contains(rowXML, expected) {
+					t.Errorf("Expected %s in row XML, got %s", expected, rowXML)
+				}
+			}
+			if strings.Contains(rowXML, "<v>NaN</v>") || strings.Contains(rowXML, "Inf</v>") {
+				t.Errorf("Non-finite value written as number: %s", rowXML)
+			}
+		})
+	}
+
+	t.Run("Error", func(t *testing.T) {
+		tmpFile := "test_non_finite.xlsx"
+		defer os.Remove(tmpFile)
+
+		sink, err := NewFileSink(tmpFile)
+		if err != nil {
+			t.Fatalf("Failed to create sink: %v", err)
+		}
+
+		config := DefaultConfig()
+		config.NonFiniteFloatPolicy = NonFiniteError
+		writer := NewWriter(sink, config)
+
+		if err := writer.StartFile(); err != nil {
+			t.Fatalf("Failed to start file: %v", err)
+		}
+		if err := writer.WriteRow([]interface{}{1, math.NaN()}); err == nil {
+			t.Error("Expected error when writing NaN with NonFiniteError policy")
+		}
+		if err := writer.WriteRow([]interface{}{1, 2.5}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+		if _, err := writer.FinishFile(); err != nil {
+			t.Fatalf("Failed to finish file: %v", err)
+		}
+
+		sheetXML := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+		if !strings.Contains(sheetXML, `<row r="1"><c r="A1"><v>1</v></c><c r="B1"><v>2.5</v></c></row>`) {
+			t.Errorf("Failed row should not be written, got %s", sheetXML)
+		}
+	})
+}
+
 func BenchmarkWriteRows(b *testing.B) {
 	tmpFile := "benchmark_output.xlsx"
 	defer os.Remove(tmpFile)
@@ -492,3 +645,170 @@ func BenchmarkWriteRows(b *testing.B) {
 		b.Fatalf("Failed to finish file: %v", err)
 	}
 }
+
+func TestSkipRowsAndWriteRowAt(t *testing.T) {
+	tmpFile := "test_skip_rows.xlsx"
+	defer os.Remove(tmpFile)
+
+	sink, err := NewFileSink(tmpFile)
+	if err != nil {
+		t.Fatalf("Failed to create sink: %v", err)
+	}
+
+	config := DefaultConfig()
+	config.MaxRowsPerSheet = 8
+	writer := NewWriter(sink, config)
+
+	if err := writer.StartFile(); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+
+	// Title block, then data from row 5
+	if err := writer.WriteRow([]interface{}{"Quarterly Report"}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+	if err := writer.SkipRows(1); err != nil {
+		t.Fatalf("Failed to skip rows: %v", err)
+	}
+	if err := writer.MergeCurrentRow(0, 3); err != nil {
+		t.Fatalf("Failed to merge title row after skipping: %v", err)
+	}
+	if err := writer.WriteRowAt(5, []interface{}{"ID", "Value"}); err != nil {
+		t.Fatalf("Failed to write row at 5: %v", err)
+	}
+	if err := writer.WriteRow([]interface{}{1, 10}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+
+	if err := writer.WriteRowAt(6, []interface{}{"again"}); err == nil {
+		t.Error("Expected error for row number that does not increase")
+	}
+	if err := writer.WriteRowAt(9, []interface{}{"beyond"}); err == nil {
+		t.Error("Expected error for row number beyond MaxRowsPerSheet")
+	}
+	if err := writer.SkipRows(-1); err == nil {
+		t.Error("Expected error for negative skip")
+	}
+
+	// Skipping past the end of the sheet does not carry over to the next one
+	if err := writer.SkipRows(10); err != nil {
+		t.Fatalf("Failed to skip rows: %v", err)
+	}
+	if err := writer.WriteRow([]interface{}{2, 20}); err != nil {
+		t.Fatalf("Failed to write row: %v", err)
+	}
+
+	stats, err := writer.FinishFile()
+	if err != nil {
+		t.Fatalf("Failed to finish file: %v", err)
+	}
+	if stats.TotalRows != 4 || stats.TotalSheets != 2 {
+		t.Errorf("Expected 4 rows on 2 sheets, got %d rows on %d sheets", stats.TotalRows, stats.TotalSheets)
+	}
+
+	sheet1 := readZipEntry(t, tmpFile, "xl/worksheets/sheet1.xml")
+	expected := []string{
+		`<row r="1"><c r="A1" t="inlineStr"><is><t>Quarterly Report</t></is></c></row>
+<row r="5"><c r="A5"`,
+		`<row r="6"><c r="A6"><v>1</v></c>`,
+		`<mergeCell ref="A1:D1"/>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheet1, e) {
+			t.Errorf("Expected %s in sheet 1, got %s", e, sheet1)
+		}
+	}
+
+	sheet2 := readZipEntry(t, tmpFile, "xl/worksheets/sheet2.xml")
+	if !strings.Contains(sheet2, `<row r="1"><c r="A1"><v>2</v></c>`) {
+		t.Errorf("Expected next row at the top of sheet 2, got %s", sheet2)
+	}
+}
+
+// failingSink accepts writes until fail is set
+type failingSink struct {
+	fail bool
+}
+
+func (s *failingSink) Write(p []byte) (int, error) {
+	if s.fail {
+		return 0, errors.New("disk full")
+	}
+	return len(p), nil
+}
+
+func (s *failingSink) Close() error { return nil }
+
+// startSpillingWriter starts a Writer whose current sheet and named sheet
+// both hold temporary files in tempDir
+func startSpillingWriter(t *testing.T, sink Sink, tempDir string) *Writer {
+	t.Helper()
+
+	config := DefaultConfig()
+	config.BufferSize = 1024
+	config.SpillThreshold = 64
+	config.TempDir = tempDir
+	writer := NewWriter(sink, config)
+	if err := writer.StartFile([]interface{}{"ID", "Name"}); err != nil {
+		t.Fatalf("Failed to start file: %v", err)
+	}
+	sheet, err := writer.Sheet("Named", []interface{}{"ID"})
+	if err != nil {
+		t.Fatalf("Failed to add sheet: %v", err)
+	}
+
+	pngData := testImage(t, 40, 20, func(b *bytes.Buffer, img image.Image) error { return png.Encode(b, img) })
+	for i := 1; i <= 200; i++ {
+		if err := writer.WriteRow([]interface{}{i, Hyperlink{URL: fmt.Sprintf("https://example.com/%d", i)}}); err != nil {
+			t.Fatalf("Failed to write row: %v", err)
+		}
+		if err := writer.AddComment(fmt.Sprintf("A%d", i+1), "QA", "Note"); err != nil {
+			t.Fatalf("Failed to add comment: %v", err)
+		}
+		if err := sheet.WriteRow([]interface{}{fmt.Sprintf("row %d", i)}); err != nil {
+			t.Fatalf("Failed to write named sheet row: %v", err)
+		}
+	}
+	if err := writer.AddImage("C2", bytes.NewReader(pngData), nil); err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+	if err := sheet.AddImage("C2", bytes.NewReader(pngData), nil); err != nil {
+		t.Fatalf("Failed to add image: %v", err)
+	}
+
+	if entries, _ := os.ReadDir(tempDir); len(entries) == 0 {
+		t.Fatal("Expected the sheets to spill to disk")
+	}
+	return writer
+}
+
+func TestFinishFileErrorRemovesTempFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	sink := &failingSink{}
+	writer := startSpillingWriter(t, sink, tempDir)
+
+	sink.fail = true
+	if _, err := writer.FinishFile(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Expected sink error, got %v", err)
+	}
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected temp files to be removed, found %d", len(entries))
+	}
+	if _, err := writer.FinishFile(); err == nil {
+		t.Error("Expected error when finishing a failed file again")
+	}
+}
+
+func TestAbort(t *testing.T) {
+	tempDir := t.TempDir()
+	writer := startSpillingWriter(t, discardSink{}, tempDir)
+
+	writer.Abort()
+	if entries, _ := os.ReadDir(tempDir); len(entries) != 0 {
+		t.Errorf("Expected temp files to be removed, found %d", len(entries))
+	}
+	if err := writer.WriteRow([]interface{}{1}); err == nil {
+		t.Error("Expected error when writing after Abort")
+	}
+	writer.Abort()
+}
@@ -1,8 +1,11 @@
 package kolayxlsxstream
 
 import (
-	"encoding/xml"
 	"fmt"
+	"maps"
+	"math"
+	"slices"
+	"strconv"
 	"strings"
 )
 
@@ -12,21 +15,20 @@ const (
 <Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
 <Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
 <Default Extension="xml" ContentType="application/xml"/>
-<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+%s<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
 <Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>
 %s</Types>`
 
-	relsXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+	relsXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
 <Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
 <Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
-</Relationships>`
+`
 
 	workbookXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
 <workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
-<sheets>`
+`
 
-	workbookXMLFooter = `</sheets>
-</workbook>`
+	workbookXMLFooter = `</workbook>`
 
 	workbookRelsXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
 <Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
@@ -34,43 +36,60 @@ const (
 	workbookRelsXMLFooter = `<Relationship Id="rId999" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
 </Relationships>`
 
-	stylesXML = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
-<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
-<fonts count="1"><font><sz val="11"/><name val="Calibri"/></font></fonts>
-<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>
-<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>
-<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>
-<cellXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/></cellXfs>
-</styleSheet>`
-
 	worksheetHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
-<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">
-<sheetData>`
+<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+`
+
+	sheetDataHeader = `<sheetData>
+`
 
-	worksheetFooter = `</sheetData>
-</worksheet>`
+	sheetDataFooter = `</sheetData>
+`
+
+	worksheetFooter = `</worksheet>`
+
+	sheetRelsXMLHeader = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`
+
+	sheetRelsXMLFooter = `</Relationships>`
 )
 
-// generateContentTypesXML generates the [Content_Types].xml with sheet overrides
-func generateContentTypesXML(sheetCount int) string {
+// generateContentTypesXML generates the [Content_Types].xml with extension
+// defaults and sheet and part overrides
+func generateContentTypesXML(sheetCount int, extensions map[string]string, parts []contentOverride) string {
+	var defaults strings.Builder
+	for _, ext := range slices.Sorted(maps.Keys(extensions)) {
+		defaults.WriteString(fmt.Sprintf(`<Default Extension="%s" ContentType="%s"/>
+`, ext, extensions[ext]))
+	}
+
 	var overrides strings.Builder
 	for i := 1; i <= sheetCount; i++ {
 		overrides.WriteString(fmt.Sprintf(`<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
 `, i))
 	}
-	return fmt.Sprintf(contentTypesXML, overrides.String())
+	for _, part := range parts {
+		overrides.WriteString(fmt.Sprintf(`<Override PartName="%s" ContentType="%s"/>
+`, part.partName, part.contentType))
+	}
+	return fmt.Sprintf(contentTypesXML, defaults.String(), overrides.String())
 }
 
 // generateWorkbookXML generates the xl/workbook.xml with sheet definitions
-func generateWorkbookXML(sheetCount int, sheetPrefix string) string {
-	var sheets strings.Builder
-	sheets.WriteString(workbookXMLHeader)
-	for i := 1; i <= sheetCount; i++ {
-		sheets.WriteString(fmt.Sprintf(`<sheet name="%s%d" sheetId="%d" r:id="rId%d"/>
-`, sheetPrefix, i, i, i))
+// and the pre-rendered <workbookProtection> and <definedNames> elements
+func generateWorkbookXML(sheetNames []string, protection, definedNames string) string {
+	var workbook strings.Builder
+	workbook.WriteString(workbookXMLHeader)
+	workbook.WriteString(protection)
+	workbook.WriteString("<sheets>")
+	for i, name := range sheetNames {
+		workbook.WriteString(fmt.Sprintf(`<sheet name="%s" sheetId="%d" r:id="rId%d"/>
+`, escapeXML(name), i+1, i+1))
 	}
-	sheets.WriteString(workbookXMLFooter)
-	return sheets.String()
+	workbook.WriteString("</sheets>\n")
+	workbook.WriteString(definedNames)
+	workbook.WriteString(workbookXMLFooter)
+	return workbook.String()
 }
 
 // generateWorkbookRelsXML generates the xl/_rels/workbook.xml.rels with sheet relationships
@@ -87,65 +106,348 @@ func generateWorkbookRelsXML(sheetCount int) string {
 
 // escapeXML escapes special characters for XML content
 func escapeXML(s string) string {
-	// Using xml.EscapeText for proper XML escaping
-	var buf strings.Builder
-	xml.EscapeText(&buf, []byte(s))
-	return buf.String()
+	return string(appendEscaped(nil, s))
 }
 
 // columnName converts a zero-based column index to Excel column name (A, B, C, ..., Z, AA, AB, ...)
 func columnName(col int) string {
-	name := ""
-	col++ // Convert to 1-based
-	for col > 0 {
+	var name [8]byte
+	i := len(name)
+	for col++; col > 0; col /= 26 { // Convert to 1-based
 		col--
-		name = string(rune('A'+(col%26))) + name
-		col /= 26
+		i--
+		name[i] = byte('A' + col%26)
 	}
-	return name
+	return string(name[i:])
 }
 
 // cellReference returns Excel cell reference (e.g., "A1", "B2", "AA10")
 func cellReference(row, col int) string {
-	return fmt.Sprintf("%s%d", columnName(col), row+1)
+	return columnName(col) + strconv.Itoa(row+1)
+}
+
+// maxColumns is Excel's maximum number of columns per sheet (A to XFD)
+const maxColumns = 16384
+
+// parseCellReference parses a cell reference such as "B12" or "$B$12" into
+// zero-based row and column indexes
+func parseCellReference(ref string) (row, col int, err error) {
+	s := strings.ReplaceAll(strings.ToUpper(strings.TrimSpace(ref)), "$", "")
+
+	i := 0
+	col = 0
+	for i < len(s) && s[i] >= 'A' && s[i] <= 'Z' {
+		col = col*26 + int(s[i]-'A') + 1
+		i++
+		if col > maxColumns {
+			return 0, 0, fmt.Errorf("invalid cell reference %q: column out of range", ref)
+		}
+	}
+	if i == 0 || i == len(s) {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+
+	rowNum, err := strconv.Atoi(s[i:])
+	if err != nil || rowNum < 1 || rowNum > 1048576 || s[i] == '+' {
+		return 0, 0, fmt.Errorf("invalid cell reference %q", ref)
+	}
+
+	return rowNum - 1, col - 1, nil
 }
 
-// generateRow generates an XML row with cells
-func generateRow(rowIndex int, values []interface{}) string {
-	var cells strings.Builder
-	cells.WriteString(fmt.Sprintf(`<row r="%d">`, rowIndex+1))
+// cellRange is a rectangular range of cells with zero-based, inclusive bounds
+type cellRange struct {
+	fromRow, fromCol int
+	toRow, toCol     int
+}
+
+// parseCellRange parses a range such as "A1:D1" (or a single cell "A1")
+func parseCellRange(ref string) (cellRange, error) {
+	first, last, isRange := strings.Cut(ref, ":")
+	fromRow, fromCol, err := parseCellReference(first)
+	if err != nil {
+		return cellRange{}, err
+	}
+	toRow, toCol := fromRow, fromCol
+	if isRange {
+		if toRow, toCol, err = parseCellReference(last); err != nil {
+			return cellRange{}, err
+		}
+	}
+	return cellRange{
+		fromRow: min(fromRow, toRow),
+		fromCol: min(fromCol, toCol),
+		toRow:   max(fromRow, toRow),
+		toCol:   max(fromCol, toCol),
+	}, nil
+}
+
+// String returns the range in A1:B2 notation
+func (r cellRange) String() string {
+	return cellReference(r.fromRow, r.fromCol) + ":" + cellReference(r.toRow, r.toCol)
+}
+
+// overlaps reports whether two ranges share at least one cell
+func (r cellRange) overlaps(o cellRange) bool {
+	return r.fromRow <= o.toRow && o.fromRow <= r.toRow &&
+		r.fromCol <= o.toCol && o.fromCol <= r.toCol
+}
+
+// appendRow appends the XML of a row with cells and optional row attributes,
+// followed by a newline
+func (sw *sheetWriter) appendRow(dst []byte, rowIndex int, values []interface{}, opts *RowOptions) ([]byte, error) {
+	dst = append(dst, `<row r="`...)
+	dst = strconv.AppendInt(dst, int64(rowIndex+1), 10)
+	dst = append(dst, '"')
+	style := 0
+	if opts != nil {
+		dst = opts.appendAttributes(dst)
+		style = opts.StyleID
+	}
+	dst = append(dst, '>')
 
 	for colIndex, value := range values {
-		ref := cellReference(rowIndex, colIndex)
-
-		switch v := value.(type) {
-		case string:
-			// String type (inline string)
-			cells.WriteString(fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`,
-				ref, escapeXML(v)))
-		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-			// Numeric types
-			cells.WriteString(fmt.Sprintf(`<c r="%s"><v>%v</v></c>`, ref, v))
-		case float32, float64:
-			// Float types
-			cells.WriteString(fmt.Sprintf(`<c r="%s"><v>%v</v></c>`, ref, v))
-		case bool:
-			// Boolean type
-			boolVal := "0"
-			if v {
-				boolVal = "1"
-			}
-			cells.WriteString(fmt.Sprintf(`<c r="%s" t="b"><v>%s</v></c>`, ref, boolVal))
-		case nil:
-			// Empty cell
-			cells.WriteString(fmt.Sprintf(`<c r="%s"/>`, ref))
-		default:
-			// Convert to string for other types
-			cells.WriteString(fmt.Sprintf(`<c r="%s" t="inlineStr"><is><t>%s</t></is></c>`,
-				ref, escapeXML(fmt.Sprintf("%v", v))))
+		if value == nil && sw.config.OmitEmptyCells {
+			continue
+		}
+		var err error
+		if dst, err = sw.appendCell(dst, sw.cellRef(rowIndex, colIndex), style, value); err != nil {
+			return dst, err
+		}
+	}
+
+	return append(dst, "</row>\n"...), nil
+}
+
+// appendCell appends the XML for a single cell with a cell style
+func (sw *sheetWriter) appendCell(dst []byte, ref cellRef, style int, value interface{}) ([]byte, error) {
+	policy := sw.config.NonFiniteFloatPolicy
+
+	switch v := value.(type) {
+	case string:
+		// String type (inline string)
+		return appendStringCell(dst, ref, style, v), nil
+	case int:
+		// Numeric types
+		return appendIntCell(dst, ref, style, int64(v)), nil
+	case int8:
+		return appendIntCell(dst, ref, style, int64(v)), nil
+	case int16:
+		return appendIntCell(dst, ref, style, int64(v)), nil
+	case int32:
+		return appendIntCell(dst, ref, style, int64(v)), nil
+	case int64:
+		return appendIntCell(dst, ref, style, v), nil
+	case uint:
+		return appendUintCell(dst, ref, style, uint64(v)), nil
+	case uint8:
+		return appendUintCell(dst, ref, style, uint64(v)), nil
+	case uint16:
+		return appendUintCell(dst, ref, style, uint64(v)), nil
+	case uint32:
+		return appendUintCell(dst, ref, style, uint64(v)), nil
+	case uint64:
+		return appendUintCell(dst, ref, style, v), nil
+	case float32:
+		// Float types
+		return appendFloatCell(dst, ref, style, float64(v), 32, policy)
+	case float64:
+		return appendFloatCell(dst, ref, style, v, 64, policy)
+	case bool:
+		// Boolean type
+		return appendBoolCell(dst, ref, style, v), nil
+	case ErrorValue:
+		// Error type
+		if !v.valid() {
+			return dst, fmt.Errorf("cell %s: unknown error value %q", ref, string(v))
 		}
+		return appendErrorCell(dst, ref, style, v), nil
+	case Cell:
+		// Value with an explicit type override or style; unstyled Cells
+		// take the style given for the cell, such as the row style
+		if v.StyleID == 0 {
+			v.StyleID = style
+		}
+		return sw.appendTypedCell(dst, ref, v)
+	case RichText:
+		// Inline string made of formatted runs
+		return appendRichTextCell(dst, ref, style, v)
+	case Hyperlink:
+		// Link cell, recorded for the sheet's <hyperlinks> element
+		return sw.appendHyperlinkCell(dst, ref, style, v)
+	case formula:
+		// Formula without a cached value, calculated when the file is opened
+		dst = appendCellStart(dst, ref, style, "")
+		dst = append(dst, `<f>`...)
+		dst = appendEscaped(dst, string(v))
+		return append(dst, `</f></c>`...), nil
+	case nil:
+		// Empty cell
+		return appendEmptyCell(dst, ref, style), nil
+	default:
+		// Convert to string for other types
+		return appendStringCell(dst, ref, style, fmt.Sprintf("%v", v)), nil
 	}
+}
+
+// formula is a cell formula generated by the writer itself, such as the
+// SUBTOTAL formulas of a table's totals row
+type formula string
+
+// appendCellStart appends the opening <c> tag of a cell with an optional
+// style and type
+func appendCellStart(dst []byte, ref cellRef, style int, cellType string) []byte {
+	dst = append(dst, `<c r="`...)
+	dst = ref.appendTo(dst)
+	dst = append(dst, '"')
+	if style > 0 {
+		dst = append(dst, ` s="`...)
+		dst = strconv.AppendInt(dst, int64(style), 10)
+		dst = append(dst, '"')
+	}
+	if cellType != "" {
+		dst = append(dst, ` t="`...)
+		dst = append(dst, cellType...)
+		dst = append(dst, '"')
+	}
+	return append(dst, '>')
+}
+
+// appendEmptyCell appends an empty cell, which only needs to be written when
+// styled
+func appendEmptyCell(dst []byte, ref cellRef, style int) []byte {
+	dst = append(dst, `<c r="`...)
+	dst = ref.appendTo(dst)
+	if style > 0 {
+		dst = append(dst, `" s="`...)
+		dst = strconv.AppendInt(dst, int64(style), 10)
+	}
+	return append(dst, `"/>`...)
+}
+
+// appendStringCell appends an inline string cell
+func appendStringCell(dst []byte, ref cellRef, style int, s string) []byte {
+	dst = appendCellStart(dst, ref, style, "inlineStr")
+	dst = append(dst, `<is>`...)
+	dst = appendText(dst, s)
+	return append(dst, `</is></c>`...)
+}
+
+// appendIntCell appends a cell with a signed integer
+func appendIntCell(dst []byte, ref cellRef, style int, v int64) []byte {
+	dst = appendCellStart(dst, ref, style, "")
+	dst = append(dst, `<v>`...)
+	dst = strconv.AppendInt(dst, v, 10)
+	return append(dst, `</v></c>`...)
+}
+
+// appendUintCell appends a cell with an unsigned integer
+func appendUintCell(dst []byte, ref cellRef, style int, v uint64) []byte {
+	dst = appendCellStart(dst, ref, style, "")
+	dst = append(dst, `<v>`...)
+	dst = strconv.AppendUint(dst, v, 10)
+	return append(dst, `</v></c>`...)
+}
+
+// appendBoolCell appends a boolean cell
+func appendBoolCell(dst []byte, ref cellRef, style int, b bool) []byte {
+	dst = appendCellStart(dst, ref, style, "b")
+	if b {
+		return append(dst, `<v>1</v></c>`...)
+	}
+	return append(dst, `<v>0</v></c>`...)
+}
+
+// appendErrorCell appends an error cell
+func appendErrorCell(dst []byte, ref cellRef, style int, e ErrorValue) []byte {
+	dst = appendCellStart(dst, ref, style, "e")
+	dst = append(dst, `<v>`...)
+	dst = appendEscaped(dst, string(e))
+	return append(dst, `</v></c>`...)
+}
+
+// formatFloat returns the shortest representation of v that round-trips
+// through strconv. Plain decimal notation is used for the magnitudes Excel
+// itself writes that way; anything else uses Excel's exponent form (1E+21).
+func formatFloat(v float64, bitSize int) string {
+	return string(appendFloat(nil, v, bitSize))
+}
+
+// appendFloatCell appends a float cell, applying the non-finite policy to
+// NaN and ±Inf values
+func appendFloatCell(dst []byte, ref cellRef, style int, v float64, bitSize int, policy NonFiniteFloatPolicy) ([]byte, error) {
+	if !math.IsNaN(v) && !math.IsInf(v, 0) {
+		dst = appendCellStart(dst, ref, style, "")
+		dst = append(dst, `<v>`...)
+		dst = appendFloat(dst, v, bitSize)
+		return append(dst, `</v></c>`...), nil
+	}
+
+	switch policy {
+	case NonFiniteNumError:
+		return appendErrorCell(dst, ref, style, ErrorNum), nil
+	case NonFiniteString:
+		return appendStringCell(dst, ref, style, strconv.FormatFloat(v, 'g', -1, bitSize)), nil
+	case NonFiniteError:
+		return dst, fmt.Errorf("cell %s: non-finite float value %v", ref, v)
+	default:
+		return appendEmptyCell(dst, ref, style), nil
+	}
+}
+
+// DataRange is a range of columns on a sheet. Rows left at zero are
+// resolved when the file is finished, so a chart can cover rows that have
+// not been written yet.
+type DataRange struct {
+	// Sheet is the 1-based sheet number; 0 is the sheet the chart is added to
+	Sheet int
+	// Column is the column letter, e.g. "B", or a span such as "A:C"
+	Column string
+	// FromRow is the first row; 0 is the first row after the header
+	FromRow int
+	// ToRow is the last row; 0 is the last data row written to the sheet
+	ToRow int
+}
+
+// validate checks the parts of the range known before the file is finished
+func (r *DataRange) validate() error {
+	if r.Sheet < 0 || r.FromRow < 0 || r.ToRow < 0 {
+		return fmt.Errorf("range sheet and rows must not be negative")
+	}
+	if r.ToRow > 0 && r.ToRow < r.FromRow {
+		return fmt.Errorf("range ends at row %d before it starts at row %d", r.ToRow, r.FromRow)
+	}
+	_, _, err := parseColumnSpan(r.Column)
+	return err
+}
+
+// resolveRange returns the absolute reference of a range, using the rows
+// written to its sheet for the bounds left at zero
+func (w *Writer) resolveRange(r DataRange) (string, error) {
+	if r.Sheet > len(w.sheetWriters) {
+		return "", fmt.Errorf("sheet %d does not exist", r.Sheet)
+	}
+	sw := w.sheetWriters[r.Sheet-1]
+
+	from, to := r.FromRow, r.ToRow
+	if from == 0 {
+		from = 1
+		if sw.headersDone {
+			from = 2
+		}
+	}
+	if to == 0 {
+		to = max(sw.dataEnd, from)
+	}
+
+	first, last, err := parseColumnSpan(r.Column)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s!$%s$%d:$%s$%d", w.sheetReference(r.Sheet), columnName(first), from, columnName(last), to), nil
+}
 
-	cells.WriteString(`</row>`)
-	return cells.String()
+// sheetReference returns a sheet's name quoted for use in formulas
+func (w *Writer) sheetReference(sheet int) string {
+	return "'" + strings.ReplaceAll(w.sheetName(sheet), "'", "''") + "'"
 }